@@ -0,0 +1,267 @@
+package crudex
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// AuditEntry records a single change of an item done through a CrudCtrl
+type AuditEntry struct {
+	ID        uint      `gorm:"primarykey"`
+	CreatedAt time.Time `gorm:"index"`
+	// TenantID is the tenant of the request that made the change, it is empty if no tenant resolver is configured
+	TenantID string `gorm:"index"`
+	// Actor is the user that made the change, resolved with the ActorResolver of the configuration
+	Actor string
+	// Model is the name of the model of the changed item
+	Model string `gorm:"index:idx_audit_record"`
+	// RecordID is the route key of the changed item
+	RecordID string `gorm:"index:idx_audit_record"`
+	// Action is one of create, update, delete, restore or purge
+	Action string
+	// Changes are the values of the changed fields before and after the change
+	Changes AuditChanges
+}
+
+// the audit entries are scoped by their own tenant field, whatever the tenant field of the models is
+func (AuditEntry) tenantField() string {
+	return "TenantID"
+}
+
+// AuditChange holds the value of a field before and after a change
+//
+// Before is omitted for the created items and After is omitted for the deleted ones
+type AuditChange struct {
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// AuditChanges maps the name of the changed fields to their change, it is stored as json
+type AuditChanges map[string]AuditChange
+
+// Value stores the changes as json
+func (self AuditChanges) Value() (driver.Value, error) {
+	data, err := json.Marshal(self)
+	return string(data), err
+}
+
+// Scan loads the changes from json
+func (self *AuditChanges) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*self = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), self)
+	case []byte:
+		return json.Unmarshal(v, self)
+	default:
+		return fmt.Errorf("Unsupported audit changes value: %T", value)
+	}
+}
+
+// GormDataType stores the changes in a text column
+func (AuditChanges) GormDataType() string {
+	return "text"
+}
+
+// IAuditSink receives the audit entries of the controllers
+type IAuditSink interface {
+	Write(c *gin.Context, entry *AuditEntry) error
+}
+
+// IAuditReader is implemented by the audit sinks that can read back the history of an item
+//
+// If the sink of the configuration implements it the `/:id/history` route of the controllers shows the history of the item.
+// The tenant is the one of the request, only the entries of the tenant must be returned
+type IAuditReader interface {
+	History(c *gin.Context, model string, recordID string, tenant string) ([]AuditEntry, error)
+}
+
+// ActionHistory shows the audit history of an item
+const ActionHistory Action = "history"
+
+// ActorResolver resolves the user that makes the request, it is recorded as the actor of the audit entries
+type ActorResolver func(c *gin.Context) string
+
+// GormAuditSink stores the audit entries in a gorm table
+//
+// The table should be migrated with `db.AutoMigrate(&crudex.AuditEntry{})`
+type GormAuditSink struct {
+	Db *gorm.DB
+}
+
+// NewGormAuditSink creates an audit sink that stores the entries in the database
+func NewGormAuditSink(db *gorm.DB) *GormAuditSink {
+	return &GormAuditSink{Db: db}
+}
+
+// Write stores the entry in the database
+func (self *GormAuditSink) Write(c *gin.Context, entry *AuditEntry) error {
+	return self.Db.Create(entry).Error
+}
+
+// History returns the entries of the item of the tenant with the newest first
+func (self *GormAuditSink) History(c *gin.Context, model string, recordID string, tenant string) ([]AuditEntry, error) {
+	var entries []AuditEntry
+	err := self.Db.Where(map[string]interface{}{"model": model, "record_id": recordID, "tenant_id": tenant}).
+		Order("created_at desc, id desc").Find(&entries).Error
+	return entries, err
+}
+
+// NewAuditCtrl creates a read-only controller that lists the audit entries stored by a GormAuditSink
+//
+// Only the ReadOnlyActions are exposed, the write routes respond with 405.
+// If the configuration has a tenant resolver the entries are scoped by the tenant of the request
+func NewAuditCtrl(db *gorm.DB, router IRouter, conf IConfig) *CrudCtrl[AuditEntry] {
+	return NewWithOptions[AuditEntry](db, router, conf).ReadOnly()
+}
+
+// audit writes the entry of the change in the audit sink of the configuration
+//
+// The change is already saved, so a failure of the sink is logged and reported with `c.Error`, but it does not fail the request.
+// The tenant of the entry is checked before the write with `checkAuditTenant`, an entry without it is never written unscoped
+func (self *CrudCtrl[T]) audit(c *gin.Context, change Change[T]) {
+	sink := self.options().AuditSink()
+	if sink == nil {
+		return
+	}
+	tenant, err := self.auditTenant(c)
+	if err != nil {
+		_ = c.Error(err)
+		slog.Error("Failed to resolve the tenant of the audit entry", slog.String("model", self.ModelName), slog.String("id", change.Key), slog.Any("error", err))
+		return
+	}
+	entry := &AuditEntry{
+		Model:    self.ModelName,
+		RecordID: change.Key,
+		Action:   string(change.Action),
+		Changes:  auditChanges(self.Schema(), change.Before, change.After),
+	}
+	if actor := self.options().ActorResolver(); actor != nil {
+		entry.Actor = actor(c)
+	}
+	entry.TenantID = tenant
+	if err := sink.Write(c, entry); err != nil {
+		_ = c.Error(err)
+		slog.Error("Failed to write the audit entry", slog.String("model", self.ModelName), slog.String("id", change.Key), slog.Any("error", err))
+	}
+}
+
+// auditChanges returns the fields that differ between the two versions of the item
+//
+// The timestamps that are managed by gorm and the fields hidden from json are left out
+func auditChanges[T IEntity](sch *schema.Schema, before, after *T) AuditChanges {
+	var zero T
+	beforeItem, afterItem := &zero, &zero
+	if before != nil {
+		beforeItem = before
+	}
+	if after != nil {
+		afterItem = after
+	}
+	beforeVal, afterVal := reflect.ValueOf(beforeItem).Elem(), reflect.ValueOf(afterItem).Elem()
+	changes := AuditChanges{}
+	for _, field := range changedFields(sch, beforeItem, afterItem) {
+		if field.AutoCreateTime > 0 || field.AutoUpdateTime > 0 || field.Tag.Get("json") == "-" {
+			continue
+		}
+		change := AuditChange{}
+		if before != nil {
+			change.Before, _ = field.ValueOf(context.Background(), beforeVal)
+		}
+		if after != nil {
+			change.After, _ = field.ValueOf(context.Background(), afterVal)
+		}
+		changes[field.Name] = change
+	}
+	return changes
+}
+
+// auditEntries returns a controller of the audit entries with the database and the configuration of the controller,
+// it stamps and scopes the entries by tenant
+func (self *CrudCtrl[T]) auditEntries() *CrudCtrl[AuditEntry] {
+	return &CrudCtrl[AuditEntry]{ModelName: "AuditEntry", Db: self.Db, Config: self.Config}
+}
+
+// auditTenant returns the tenant of the request as it is stamped on the audit entries, it is empty if the entries are not scoped by tenant
+//
+// It returns an *HttpError with status 403 if the entries are scoped but the tenant of the request is unknown
+func (self *CrudCtrl[T]) auditTenant(c *gin.Context) (string, error) {
+	var entry AuditEntry
+	if err := self.auditEntries().stampTenant(c, &entry); err != nil {
+		return "", err
+	}
+	return entry.TenantID, nil
+}
+
+// checkAuditTenant fails a write whose audit entry can not be stamped with the tenant of the request,
+// so the changes are never left unscoped in the audit log
+func (self *CrudCtrl[T]) checkAuditTenant(c *gin.Context) error {
+	if self.options().AuditSink() == nil {
+		return nil
+	}
+	_, err := self.auditTenant(c)
+	return err
+}
+
+// auditReader returns the audit sink of the configuration if it can read back the history of the items
+func (self *CrudCtrl[T]) auditReader() IAuditReader {
+	reader, _ := self.options().AuditSink().(IAuditReader)
+	return reader
+}
+
+// HasHistory returns true if the audit sink of the configuration can read back the history of the items
+func (self *CrudCtrl[T]) HasHistory() bool {
+	return self.auditReader() != nil
+}
+
+// History is a handler that shows the audit entries of an item of the model, with the newest first
+// it is a GET request
+// !Requires the template to be named as modelName-history.html where the modelName is lowercased model name
+func (self *CrudCtrl[T]) History(c *gin.Context) {
+	reader := self.auditReader()
+	if reader == nil {
+		self.fail(c, http.StatusNotFound, NewHttpError(http.StatusNotFound, fmt.Sprintf("%s has no history", self.ModelName)))
+		return
+	}
+	key, err := self.parseKey(c)
+	if err != nil {
+		self.fail(c, http.StatusBadRequest, err)
+		return
+	}
+	var item T
+	// the history of the items in the trash is kept as well
+	if err := self.findIn(self.scope(c, self.Db).Unscoped(), key, &item); err != nil {
+		self.fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	if err := self.authorize(c, ActionHistory, &item); err != nil {
+		self.fail(c, http.StatusForbidden, err)
+		return
+	}
+	tenant, err := self.auditTenant(c)
+	if err != nil {
+		self.fail(c, http.StatusForbidden, err)
+		return
+	}
+	entries, err := reader.History(c, self.ModelName, self.Keys.Format(&item), tenant)
+	if err != nil {
+		self.fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	self.Respond(c,
+		gin.H{self.ModelName: item, "AuditEntryList": entries, "Path": fmt.Sprintf("%s/%s", self.BasePath(), rawParam(c, "id"))},
+		fmt.Sprintf("%s-history.html", strings.ToLower(self.ModelName)))
+}
@@ -2,11 +2,21 @@ package crudex
 
 import "gorm.io/gorm"
 
+// IEntity is the constraint for the models that are managed by crudex
+//
+// The primary key of the model is resolved from its gorm schema(see `IKeyResolver`),
+// so the models are free to use uint, string, uuid or composite keys and do not have to implement IModel
+type IEntity interface{}
+
+// IModel is implemented by the models with a uint key, e.g. the ones that embed BaseModel
+//
+// The controllers do not require it, but the models that implement it are handled the same way as before
 type IModel interface {
 	GetID() uint
 	SetID(id uint)
 }
 
+// BaseModel is a model with an auto incremented uint key and soft deletes, based on gorm.Model
 type BaseModel struct {
 	gorm.Model
 }
@@ -15,6 +25,6 @@ func (self BaseModel) GetID() uint {
 	return self.ID
 }
 
-func (self BaseModel) SetID(id uint) {
+func (self *BaseModel) SetID(id uint) {
 	self.ID = id
 }
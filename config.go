@@ -26,6 +26,9 @@ type Config struct {
 	//the layout to use on the templates for full page rendering
 	layoutName string
 
+	//the template used to render the errors for the UI requests
+	errorTemplate string
+
 	//if true the layout will be used even if the request is not an Htmx request,
 	//otherwise the template will be rendered without the layout
 	enableLayoutOnNonHxRequest bool
@@ -50,6 +53,34 @@ type Config struct {
 
 	// wether to auto scaffold the templates when a new controller is created
 	autoScaffold bool
+
+	// wether to permanently delete the items even if the model supports soft deletes
+	hardDelete bool
+
+	// resolves the tenant of the request, the queries are not scoped if it is nil
+	tenantResolver TenantResolver
+
+	// the name of the model field that holds the tenant
+	tenantField string
+
+	// receives the audit entries of the changes, the changes are not audited if it is nil
+	auditSink IAuditSink
+
+	// resolves the user that makes the request
+	actorResolver ActorResolver
+
+	// applies the free text search of the lists
+	searcher Searcher
+
+	// queues the changes for the webhooks, the webhooks are not used if it is nil
+	webhooks IWebhookQueue
+
+	// receives the changes of the controllers for the subscribers in the application
+	eventBus *EventBus
+
+	// the page size of the lists when `$top` is not set, and the largest accepted `$top`
+	defaultPageSize int
+	maxPageSize     int
 }
 
 // NewConfig creates a new configuration crud configuration containing all the defaults
@@ -78,6 +109,12 @@ func NewConfig() *Config {
 
 		templateDirs:               []string{"gen", "templates"},
 		layoutName:                 "index.html",
+		errorTemplate:              "error.html",
+		tenantField:                "TenantID",
+		searcher:                   SearchLike(),
+		eventBus:                   NewEventBus(),
+		defaultPageSize:            50,
+		maxPageSize:                500,
 		enableLayoutOnNonHxRequest: true,
 		layoutDataFunc:             nil,
 
@@ -91,6 +128,24 @@ func NewConfig() *Config {
 	}
 }
 
+// defaultOptions are the options of the configurations that do not implement IConfigOptions, the same as the defaults of NewConfig
+// without the features that have to be set up
+var defaultOptions IConfigOptions = &Config{
+	errorTemplate:   "error.html",
+	tenantField:     "TenantID",
+	searcher:        SearchLike(),
+	defaultPageSize: 50,
+	maxPageSize:     500,
+}
+
+// configOptions returns the options of the configuration, or the default ones if it does not implement IConfigOptions
+func configOptions(conf IConfig) IConfigOptions {
+	if options, ok := conf.(IConfigOptions); ok {
+		return options
+	}
+	return defaultOptions
+}
+
 func (conf *Config) String() string {
 	return fmt.Sprintf(`
 #############################################
@@ -138,6 +193,11 @@ func (conf *Config) LayoutName() string {
 	return conf.layoutName
 }
 
+// the template used to render the errors for the UI requests
+func (conf *Config) ErrorTemplate() string {
+	return conf.errorTemplate
+}
+
 // if true the layout will be used even if the request is not an Htmx request, otherwise the template will be rendered without the layout
 func (conf *Config) EnableLayoutOnNonHxRequest() bool {
 	return conf.enableLayoutOnNonHxRequest
@@ -197,6 +257,56 @@ func (conf *Config) AutoScaffold() bool {
 	return conf.autoScaffold
 }
 
+// HardDelete returns true if the items are permanently deleted even if the model supports soft deletes
+func (conf *Config) HardDelete() bool {
+	return conf.hardDelete
+}
+
+// TenantResolver returns the function that resolves the tenant of the request, or nil if the queries are not scoped by tenant
+func (conf *Config) TenantResolver() TenantResolver {
+	return conf.tenantResolver
+}
+
+// TenantField returns the name of the model field that holds the tenant
+func (conf *Config) TenantField() string {
+	return conf.tenantField
+}
+
+// AuditSink returns the sink of the audit entries, or nil if the changes are not audited
+func (conf *Config) AuditSink() IAuditSink {
+	return conf.auditSink
+}
+
+// ActorResolver returns the function that resolves the user that makes the request
+func (conf *Config) ActorResolver() ActorResolver {
+	return conf.actorResolver
+}
+
+// Searcher returns the function that applies the `?q=` search of the lists
+func (conf *Config) Searcher() Searcher {
+	return conf.searcher
+}
+
+// Webhooks returns the queue of the webhooks, or nil if the changes are not sent to webhooks
+func (conf *Config) Webhooks() IWebhookQueue {
+	return conf.webhooks
+}
+
+// EventBus returns the event bus that receives the changes of the controllers, see `Subscribe`
+func (conf *Config) EventBus() *EventBus {
+	return conf.eventBus
+}
+
+// DefaultPageSize returns the page size of the lists when `$top` is not set, 0 if the lists are not paged by default
+func (conf *Config) DefaultPageSize() int {
+	return conf.defaultPageSize
+}
+
+// MaxPageSize returns the largest page size of the lists, 0 if it is not limited
+func (conf *Config) MaxPageSize() int {
+	return conf.maxPageSize
+}
+
 // WithScaffoldStrategy sets the strategy to use when creating the scaffolded templates
 // The default is ScaffoldCreateAlways, options are ScaffoldCreateAlways, ScaffoldCreateIfNotExist, ScaffoldCreateNever
 // This option is not used at the moment
@@ -217,6 +327,12 @@ func (c *Config) WithLayoutName(layoutName string) *Config {
 	return c
 }
 
+// WithErrorTemplate sets the template used to render the errors for the UI requests
+func (c *Config) WithErrorTemplate(errorTemplate string) *Config {
+	c.errorTemplate = errorTemplate
+	return c
+}
+
 // WithLayoutDataFunc is function that is used to supply the layout with the data needed to render the layout
 func (c *Config) WithLayoutDataFunc(layoutDataFunc func(c *gin.Context, data gin.H)) *Config {
 	c.layoutDataFunc = layoutDataFunc
@@ -277,6 +393,76 @@ func (conf *Config) WithAutoScaffold(value bool) *Config {
 	return conf
 }
 
+// WithHardDelete sets the configuration to permanently delete the items even if the model supports soft deletes
+//
+// The soft deleted items are still available in the trash of the controllers
+func (conf *Config) WithHardDelete(value bool) *Config {
+	conf.hardDelete = value
+	return conf
+}
+
+// WithTenantResolver sets the function that resolves the tenant of the request
+//
+// The models that have the tenant field are scoped to the tenant of the request on every query,
+// and the tenant is stamped on the items that are saved. See `TenantFromHeader`, `TenantFromSubdomain` and `TenantFromContext`
+func (conf *Config) WithTenantResolver(resolver TenantResolver) *Config {
+	conf.tenantResolver = resolver
+	return conf
+}
+
+// WithAuditSink sets the sink that receives an audit entry for every change done through the controllers
+//
+// Use `NewGormAuditSink` to store the entries in the database
+func (conf *Config) WithAuditSink(sink IAuditSink) *Config {
+	conf.auditSink = sink
+	return conf
+}
+
+// WithActorResolver sets the function that resolves the user that makes the request, e.g. from the session
+func (conf *Config) WithActorResolver(resolver ActorResolver) *Config {
+	conf.actorResolver = resolver
+	return conf
+}
+
+// WithSearcher sets the function that applies the `?q=` search of the lists, the default is `SearchLike()`
+//
+// Use `SearchFTS5` for SQLite full text search tables or `SearchTsvector` for the Postgres full text search
+func (conf *Config) WithSearcher(searcher Searcher) *Config {
+	conf.searcher = searcher
+	return conf
+}
+
+// WithWebhooks queues the changes of the controllers for the webhooks, usually in a WebhookDispatcher
+//
+// The deliveries are sent by the dispatcher, call `dispatcher.Start(ctx, time.Minute)` to send them in the background
+func (conf *Config) WithWebhooks(dispatcher IWebhookQueue) *Config {
+	conf.webhooks = dispatcher
+	return conf
+}
+
+// WithEventBus sets the event bus that receives the changes of the controllers, e.g. to share a bus between configurations
+//
+// Every configuration has its own bus by default, the changes are not published if it is nil
+func (conf *Config) WithEventBus(bus *EventBus) *Config {
+	conf.eventBus = bus
+	return conf
+}
+
+// WithPageSize sets the page size of the lists when `$top` is not set and the largest accepted `$top`, the defaults are 50 and 500
+//
+// A larger `$top` is reduced to the maximum, so a single request can not load a whole table. Use 0 to disable the limits
+func (conf *Config) WithPageSize(defaultSize int, maxSize int) *Config {
+	conf.defaultPageSize = defaultSize
+	conf.maxPageSize = maxSize
+	return conf
+}
+
+// WithTenantField sets the name of the model field that holds the tenant, the default is `TenantID`
+func (conf *Config) WithTenantField(name string) *Config {
+	conf.tenantField = name
+	return conf
+}
+
 // WithCommandLineArgs sets the configuration from the command line arguments
 func (conf *Config) WithCommandLineArgs(args []string) *Config {
 	var templateDirs string
@@ -1,23 +1,26 @@
 package crudex
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
-	"strconv"
+	"path/filepath"
+	"reflect"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	odata "github.com/pboyd04/godata"
 	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
 )
 
 // FormBinder is a function that binds the form data to a model
-type FormBinder[T IModel] func(c *gin.Context, out *T) error
+type FormBinder[T IEntity] func(c *gin.Context, out *T) error
 
 // CrudCtrl is a controller that implements the basic CRUD operations for a model with a gorm backend
-type CrudCtrl[T IModel] struct {
+type CrudCtrl[T IEntity] struct {
 	Db     *gorm.DB
 	Router IRouter
 	Config IConfig
@@ -25,6 +28,31 @@ type CrudCtrl[T IModel] struct {
 	ModelName    string
 	ModelKeyName string
 	FormBinder   FormBinder[T]
+	JSONBinder   FormBinder[T]
+
+	// Hooks are the lifecycle hooks that are invoked on create, update and delete
+	Hooks Hooks[T]
+
+	// Keys converts the primary key of the model between the routes and the model
+	Keys IKeyResolver[T]
+
+	// Authorizer is consulted before every action of the controller, every action is allowed if it is nil
+	Authorizer IAuthorizer[T]
+
+	// actions are the actions exposed by the controller, every action is exposed if it is nil
+	actions map[Action]bool
+	// disabled are the actions that are not exposed, even if they are in the actions
+	disabled map[Action]bool
+
+	// customActions are the custom actions of the controller, see `WithCustomAction`
+	customActions  []CustomAction
+	customHandlers []Hook[T]
+
+	// cursorPaging pages the list with cursors by default, see `WithCursorPaging`
+	cursorPaging bool
+
+	// stream sends the changes to the subscribers of the `/events` route, it is nil if the change stream is not enabled
+	stream *changeStream[T]
 }
 
 // Returns the Name of the model
@@ -32,6 +60,11 @@ func (self *CrudCtrl[T]) GetModelName() string {
 	return self.ModelName
 }
 
+// options returns the optional settings of the configuration of the controller, see IConfigOptions
+func (self *CrudCtrl[T]) options() IConfigOptions {
+	return configOptions(self.Config)
+}
+
 // BasePath returns the base path of the controller
 func (self *CrudCtrl[T]) BasePath() string {
 	return self.Router.BasePath()
@@ -41,7 +74,7 @@ func (self *CrudCtrl[T]) BasePath() string {
 //
 // It uses the default configuration
 // See `NewWithOptions` for more control over the configuration
-func New[T IModel]() *CrudCtrl[T] {
+func New[T IEntity]() *CrudCtrl[T] {
 	conf := GetConfig()
 	db := conf.DefaultDb()
 	modelType := extractType(*new(T))
@@ -53,13 +86,15 @@ func New[T IModel]() *CrudCtrl[T] {
 // New creates a new CRUD controller for the provided model
 //
 // It uses the provided configuration to define its behaviour
-func NewWithOptions[T IModel](db *gorm.DB, router IRouter, conf IConfig) *CrudCtrl[T] {
+func NewWithOptions[T IEntity](db *gorm.DB, router IRouter, conf IConfig) *CrudCtrl[T] {
 	var name = fmt.Sprintf("%T", *new(T))
 	if strings.Contains(name, ".") {
 		name = strings.Split(name, ".")[1]
 	}
 	res := &CrudCtrl[T]{
 		FormBinder: DefaultFormHandler[T], // default form handler is used if none is provided
+		JSONBinder: DefaultJSONHandler[T], // default json handler is used if none is provided
+		Keys:       NewSchemaKeyResolver[T](db),
 		ModelName:  name,
 		Db:         db,
 		Config:     conf,
@@ -92,18 +127,41 @@ func (self *CrudCtrl[T]) EnableRoutes(r IRouter) *CrudCtrl[T] {
 	if r == nil {
 		panic("Router is nil, cannot enable routes")
 	}
-	r.GET("/", self.List)
+	r.GET("/", self.requireAction(self.List, ActionList))
+	r.GET("/export", self.requireAction(self.Export, ActionList))
 
 	if self.Config.HasUI() {
-		r.GET("/new", self.Form)
-		r.GET("/:id/edit", self.Form)
+		r.GET("/new", self.requireAction(self.Form, ActionCreate))
+		r.GET("/:id/edit", self.requireAction(self.Form, ActionUpdate))
+		r.GET("/import", self.requireAction(self.ImportForm, ActionCreate, ActionUpdate))
 	}
 
-	r.PUT("/new", self.Upsert)
+	r.PUT("/new", self.requireAction(self.Upsert, ActionCreate))
+	r.PUT("/bulk", self.requireAction(self.BulkCreate, ActionCreate))
+	r.POST("/bulk", self.requireAction(self.BulkUpsert, ActionCreate, ActionUpdate))
+	r.DELETE("/bulk", self.requireAction(self.BulkDelete, ActionDelete))
+	r.POST("/import", self.requireAction(self.Import, ActionCreate, ActionUpdate))
+
+	r.GET("/:id", self.requireAction(self.Details, ActionDetails))
+	r.POST("/:id", self.requireAction(self.Upsert, ActionUpdate))
+	r.PATCH("/:id", self.requireAction(self.Patch, ActionUpdate))
+	r.DELETE("/:id", self.requireAction(self.Delete, ActionDelete))
 
-	r.GET("/:id", self.Details)
-	r.POST("/:id", self.Upsert)
-	r.DELETE("/:id", self.Delete)
+	if self.SoftDeleteField() != nil {
+		r.GET("/trash", self.requireAction(self.Trash, ActionList))
+		r.POST("/:id/restore", self.requireAction(self.Restore, ActionUpdate))
+		r.DELETE("/:id/purge", self.requireAction(self.Purge, ActionDelete))
+	}
+	r.GET("/:id/history", self.requireAction(self.History, ActionHistory))
+	for _, rel := range self.Relations() {
+		r.GET(fmt.Sprintf("/:id/%s", strings.ToLower(rel.Name)), self.requireAction(self.Related(rel), ActionDetails))
+	}
+	for i, action := range self.customActions {
+		self.enableCustomAction(r, action, self.customHandlers[i])
+	}
+	if self.stream != nil {
+		r.GET("/events", self.requireAction(self.Events, ActionList))
+	}
 	return self
 }
 
@@ -118,6 +176,19 @@ func (self *CrudCtrl[T]) ScaffoldDefaults() *CrudCtrl[T] {
 	GenListTmpl(model, rootDir)
 	GenDetailTmpl(model, rootDir)
 	GenFormTmpl(model, rootDir)
+	GenImportTmpl(model, rootDir)
+	GenErrorTmpl(filepath.Join(rootDir, self.options().ErrorTemplate()))
+	if self.SoftDeleteField() != nil {
+		GenTrashTmpl(model, rootDir)
+	}
+	if self.auditReader() != nil {
+		GenHistoryTmpl(model, rootDir)
+	}
+	for _, rel := range self.Relations() {
+		if rel.Type == schema.HasMany {
+			GenRelatedTmpl(reflect.New(rel.FieldSchema.ModelType).Elem().Interface(), rootDir)
+		}
+	}
 	return self
 }
 
@@ -145,21 +216,101 @@ func (self *CrudCtrl[T]) WithFormBinder(handler FormBinder[T]) *CrudCtrl[T] {
 	return self
 }
 
+// WithJSONBinder sets the binder for the controller to be used when binding json data to the model on the POST and PUT requests.
+// It is used in the Upsert method of the controller when the request has an `application/json` content type
+// If not set, the default json binder is used which respects the `json` tags of the model
+func (self *CrudCtrl[T]) WithJSONBinder(handler FormBinder[T]) *CrudCtrl[T] {
+	self.JSONBinder = handler
+	return self
+}
+
+// WithKeyResolver sets the resolver that converts the primary key of the model between the routes and the model
+// If not set, the key is resolved from the primary fields of the gorm schema of the model
+func (self *CrudCtrl[T]) WithKeyResolver(resolver IKeyResolver[T]) *CrudCtrl[T] {
+	self.Keys = resolver
+	return self
+}
+
+// Bind binds the request body to the item using the binder that matches the request content type
+//
+//   - application/json is bound with the JSONBinder
+//   - application/x-www-form-urlencoded and multipart/form-data are bound with the FormBinder
+//
+// Any other content type results with an *HttpError with status 415
+func (self *CrudCtrl[T]) Bind(c *gin.Context, out *T) error {
+	switch c.ContentType() {
+	case gin.MIMEJSON:
+		return self.JSONBinder(c, out)
+	case gin.MIMEPOSTForm, gin.MIMEMultipartPOSTForm, "":
+		return self.FormBinder(c, out)
+	default:
+		msg := fmt.Sprintf("Unsupported content type: %s", c.ContentType())
+		return NewHttpError(http.StatusUnsupportedMediaType, msg)
+	}
+}
+
 // List is a handler that lists all the items of the model
 // it is a GET request
+//
+// The list is paged, the json response contains the `items`, the `links` of the next and previous pages, the `page`
+// and the total `count` with `$count=true`. The templates get the items as `<Model>List` with the Page, see `listData`
 // !Requres the template to be named as modelName-list.html where the modelName is lowercased model name
 func (self *CrudCtrl[T]) List(c *gin.Context) {
+	if err := self.authorize(c, ActionList, nil); err != nil {
+		self.fail(c, http.StatusForbidden, err)
+		return
+	}
 	var items []T
-	dbRes, error := odata.GetGormSettingsFromGin(c, self.Db)
-	if error != nil {
-		c.String(http.StatusBadRequest, c.Error(error).Error())
-		c.Abort()
+	dbRes, err := odata.GetGormSettingsFromGin(c, self.scope(c, self.Db))
+	if err != nil {
+		self.fail(c, http.StatusBadRequest, err)
+		return
+	}
+	if dbRes, err = self.Search(c, dbRes); err != nil {
+		self.fail(c, http.StatusBadRequest, err)
+		return
+	}
+	if dbRes, err = self.Filter(c, dbRes); err != nil {
+		self.fail(c, http.StatusBadRequest, err)
+		return
+	}
+	ui := negotiate(c, self.Config) == responseUI
+	page := self.Paging(c)
+	if err := self.countItems(c, dbRes, page, ui); err != nil {
+		self.fail(c, http.StatusInternalServerError, err)
 		return
 	}
-	dbRes.Find(&items)
-	self.Respond(c,
-		gin.H{fmt.Sprintf("%sList", self.ModelName): &items, "Path": self.Router.BasePath()},
-		fmt.Sprintf("%s-list.html", strings.ToLower(self.ModelName)))
+	if dbRes, err = self.Expand(c, dbRes); err != nil {
+		self.fail(c, http.StatusBadRequest, err)
+		return
+	}
+	projection, err := self.Selection(c)
+	if err != nil {
+		self.fail(c, http.StatusBadRequest, err)
+		return
+	}
+	if _, ok := c.GetQuery(CursorParam); ok || self.cursorPaging {
+		if dbRes, err = self.keyset(c, dbRes, page, projection); err != nil {
+			self.fail(c, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if err := self.paginate(c, projection.Apply(dbRes), page, &items); err != nil {
+		self.fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	var list interface{} = &items
+	if projection != nil {
+		list = projection.ProjectAll(items, ui)
+	}
+	data := listData(c, self.Config, self.ModelName, list, self.Router.BasePath(), page)
+	if ui {
+		data["Search"] = c.Query("q")
+		data["Query"] = c.Request.URL.Query()
+		data["Sort"], data["SortOrder"] = self.sortFuncs(c)
+		data["ChangeStream"] = self.HasChangeStream()
+	}
+	self.Respond(c, data, fmt.Sprintf("%s-list.html", strings.ToLower(self.ModelName)))
 }
 
 // Details is a handler that shows the details of a single item of the model
@@ -167,94 +318,304 @@ func (self *CrudCtrl[T]) List(c *gin.Context) {
 // !Requires the template to be named as modelName-details.html where the modelName is lowercased model name
 func (self *CrudCtrl[T]) Details(c *gin.Context) {
 	template := fmt.Sprintf("%s.html", strings.ToLower(self.ModelName))
-	idStr := c.Param("id")
-	id, err := strconv.ParseUint(idStr, 10, 64)
-	if err == nil {
-		var item T
-		self.Db.First(&item, id)
-		self.Respond(c, gin.H{self.ModelName: item, "Path": fmt.Sprintf("%s/%s", self.Router.BasePath(), idStr)}, template)
-	} else {
-		_ = c.Error(err)
-		c.String(http.StatusBadRequest, fmt.Sprintf("Invalid ID for %s: %d", self.ModelName, id))
+	key, err := self.parseKey(c)
+	if err != nil {
+		self.fail(c, http.StatusBadRequest, err)
+		return
+	}
+	db, err := self.Expand(c, self.scope(c, self.Db))
+	if err != nil {
+		self.fail(c, http.StatusBadRequest, err)
+		return
 	}
+	projection, err := self.Selection(c)
+	if err != nil {
+		self.fail(c, http.StatusBadRequest, err)
+		return
+	}
+	var item T
+	if err := self.findIn(projection.Apply(db), key, &item); err != nil {
+		self.fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	if err := self.authorize(c, ActionDetails, &item); err != nil {
+		self.fail(c, http.StatusForbidden, err)
+		return
+	}
+	if etag := self.ETag(&item); etag != "" {
+		c.Header("ETag", etag)
+	}
+	var data interface{} = item
+	if projection != nil {
+		data = projection.Project(&item)
+		if negotiate(c, self.Config) == responseUI {
+			data = projection.Values(&item)
+		}
+	}
+	self.Respond(c, gin.H{self.ModelName: data, "Path": fmt.Sprintf("%s/%s", self.Router.BasePath(), rawParam(c, "id"))}, template)
 }
 
-
 // Form is a handler that shows the form for editing an item of the model
 // it is a GET request
 // !Requires the template to be named as modelName-edit.html where the modelName is lowercased model name
 func (self *CrudCtrl[T]) Form(c *gin.Context) {
 	template := fmt.Sprintf("%s-form.html", strings.ToLower(self.ModelName))
-	idStr := c.Param("id")
-	if idStr == "" {
-		self.Respond(c, gin.H{"Path": self.Router.BasePath()}, template)
-	} else {
-		id, err := strconv.ParseUint(idStr, 10, 64)
-		if err == nil {
-			var item T
-			self.Db.First(&item, id)
-			self.Respond(c, gin.H{self.ModelName: item, "Path": fmt.Sprintf("%s/%s", self.Router.BasePath(), idStr)}, template)
-		} else {
-			_ = c.Error(err)
-			c.String(http.StatusBadRequest, fmt.Sprintf("Invalid ID for %s: %d", self.ModelName, id))
+	if c.Param("id") == "" {
+		if err := self.authorize(c, ActionCreate, nil); err != nil {
+			self.fail(c, http.StatusForbidden, err)
+			return
 		}
+		var item T
+		self.Respond(c, self.formData(c, item, nil), template)
+		return
+	}
+	key, err := self.parseKey(c)
+	if err != nil {
+		self.fail(c, http.StatusBadRequest, err)
+		return
+	}
+	var item T
+	if err := self.find(c, key, &item); err != nil {
+		self.fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	if err := self.authorize(c, ActionUpdate, &item); err != nil {
+		self.fail(c, http.StatusForbidden, err)
+		return
+	}
+	data := self.formData(c, item, &item)
+	if etag := data["ETag"].(string); etag != "" {
+		c.Header("ETag", etag)
+	}
+	self.Respond(c, data, template)
+}
+
+// formData returns the data of the form template for the item,
+// the current version is the saved item that is edited, or nil for a new item
+func (self *CrudCtrl[T]) formData(c *gin.Context, item T, current *T) gin.H {
+	path, etag := self.BasePath(), ""
+	if current != nil {
+		path = fmt.Sprintf("%s/%s", self.BasePath(), rawParam(c, "id"))
+		etag = self.ETag(current)
 	}
+	return gin.H{self.ModelName: item, "IsNew": current == nil, "ETag": etag, "Path": path}
 }
 
 // Upsert is a handler that saves an item of the model
 // it is a POST or PUT request depending on the presence of the id parameter
-// !Requires the form fields to be named as the model field names(case sensitive)
+// The body is bound according to its content type, see `Bind`
 // It redirects to the details page of the saved item
 func (self *CrudCtrl[T]) Upsert(c *gin.Context) {
+	isNew := c.Param("id") == ""
+	var key Key
+	var current *T
+	if !isNew {
+		var err error
+		if key, err = self.parseKey(c); err != nil {
+			self.fail(c, http.StatusBadRequest, err)
+			return
+		}
+		current = new(T)
+		if err := self.find(c, key, current); err != nil {
+			self.fail(c, http.StatusInternalServerError, err)
+			return
+		}
+		if err := self.authorize(c, ActionUpdate, current); err != nil {
+			self.fail(c, http.StatusForbidden, err)
+			return
+		}
+		if err := self.CheckPrecondition(c, current); err != nil {
+			self.fail(c, http.StatusPreconditionFailed, err)
+			return
+		}
+	} else if err := self.authorize(c, ActionCreate, nil); err != nil {
+		self.fail(c, http.StatusForbidden, err)
+		return
+	}
 	var item T
-	if err := self.FormBinder(c, &item); err != nil {
-		c.String(http.StatusBadRequest, c.Error(err).Error())
-		c.Abort()
+	if err := self.Hooks.run(c, self.Hooks.BeforeBind, &item); err != nil {
+		self.fail(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := self.Bind(c, &item); err != nil {
+		self.failValidation(c, http.StatusBadRequest, err, &item, current)
+		return
+	}
+	if err := self.Hooks.run(c, self.Hooks.AfterBind, &item); err != nil {
+		self.fail(c, http.StatusBadRequest, err)
 		return
 	}
-	idStr := c.Param("id")
-	isNew := idStr == ""
 	if !isNew {
-		id, err := strconv.ParseInt(idStr, 10, 64)
-		if err != nil {
-			msg := fmt.Sprintf("Invalid ID: %d", id)
-			c.String(http.StatusBadRequest, msg)
-			c.Abort()
+		if err := self.Keys.Apply(&item, key); err != nil {
+			self.fail(c, http.StatusBadRequest, err)
 			return
 		}
-		item.SetID(uint(id))
 	}
-	res := self.Db.Save(&item)
-
-	if res.Error != nil {
-		c.String(http.StatusBadRequest, c.Error(res.Error).Error())
-		c.Abort()
+	if err := self.stampTenant(c, &item); err != nil {
+		self.fail(c, http.StatusForbidden, err)
+		return
+	}
+	action := ActionUpdate
+	if isNew {
+		action = ActionCreate
+	}
+	// the bound item is authorized as well, so it can not be created or moved outside of what the user can change
+	if err := self.authorize(c, action, &item); err != nil {
+		self.fail(c, http.StatusForbidden, err)
+		return
+	}
+	if err := self.Validate(c, &item); err != nil {
+		self.failValidation(c, http.StatusUnprocessableEntity, err, &item, current)
+		return
+	}
+	if err := self.Hooks.run(c, self.Hooks.BeforeSave, &item); err != nil {
+		self.fail(c, http.StatusBadRequest, err)
+		return
+	}
+	change, err := self.commit(c, func(tx *gorm.DB) (*Change[T], error) {
+		var result *gorm.DB
+		if isNew {
+			// a new item never overwrites an existing one, even if the body carries its key
+			result = tx.Create(&item)
+		} else {
+			// every column is selected so gorm does not insert the item when the update does not match it
+			result = self.whereVersion(c, tx, current).Select("*").Save(&item)
+		}
+		if err := self.checkWritten(c, result, &item); err != nil {
+			return nil, err
+		}
+		self.refreshVersion(tx, &item)
+		return &Change[T]{Action: action, Key: self.Keys.Format(&item), Before: current, After: &item}, nil
+	})
+	if err != nil {
+		self.fail(c, http.StatusInternalServerError, err)
 		return
 	}
-	c.Header("HX-Redirect", fmt.Sprintf("%s/%d", self.BasePath(), item.GetID()))
+	if err := self.Hooks.run(c, self.Hooks.AfterSave, &item); err != nil {
+		self.fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	self.notify(c, *change)
+	if etag := self.ETag(&item); etag != "" {
+		c.Header("ETag", etag)
+	}
+	c.Header("HX-Redirect", fmt.Sprintf("%s/%s", self.BasePath(), self.Keys.Format(&item)))
 	c.String(http.StatusOK, "Saved")
 	c.Abort()
 }
 
 // Delete is a handler that deletes an item of the model
 // it is a DELETE request
+// The item is soft deleted if the model supports it, unless the configuration enforces hard deletes
 func (self *CrudCtrl[T]) Delete(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.ParseUint(idStr, 10, 64)
+	key, err := self.parseKey(c)
 	if err != nil {
-		msg := fmt.Sprintf("Invalid ID: %d", id)
-		c.String(http.StatusBadRequest, msg)
-		c.Abort()
+		self.fail(c, http.StatusBadRequest, err)
 		return
 	}
 	var item T
-	self.Db.Delete(&item, id)
+	if err := self.find(c, key, &item); err != nil {
+		self.fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	if err := self.authorize(c, ActionDelete, &item); err != nil {
+		self.fail(c, http.StatusForbidden, err)
+		return
+	}
+	if err := self.CheckPrecondition(c, &item); err != nil {
+		self.fail(c, http.StatusPreconditionFailed, err)
+		return
+	}
+	if err := self.Hooks.run(c, self.Hooks.BeforeDelete, &item); err != nil {
+		self.fail(c, http.StatusBadRequest, err)
+		return
+	}
+	change, err := self.commit(c, func(tx *gorm.DB) (*Change[T], error) {
+		if self.options().HardDelete() {
+			tx = tx.Unscoped()
+		}
+		if err := self.checkWritten(c, self.whereVersion(c, tx, &item).Delete(&item), &item); err != nil {
+			return nil, err
+		}
+		return &Change[T]{Action: ActionDelete, Key: self.Keys.Format(&item), Before: &item}, nil
+	})
+	if err != nil {
+		self.fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	if err := self.Hooks.run(c, self.Hooks.AfterDelete, &item); err != nil {
+		self.fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	self.notify(c, *change)
 	c.Header("HX-Redirect", self.Router.BasePath())
 	c.String(http.StatusOK, "Deleted")
 	c.Abort()
 }
 
+// parseKey parses the id route parameter of the request into the key of the model
+func (self *CrudCtrl[T]) parseKey(c *gin.Context) (Key, error) {
+	key, err := self.Keys.Parse(rawParam(c, "id"))
+	if err != nil {
+		return nil, WrapHttpError(http.StatusBadRequest, fmt.Errorf("Invalid ID for %s: %s", self.ModelName, c.Param("id")))
+	}
+	return key, nil
+}
+
+// rawParam returns the route parameter as it was sent in the url, before it was unescaped by the router
+//
+// The key values are escaped with `escapeKeyValue`, so an escaped `KeySeparator` in a value is not mistaken for a separator
+func rawParam(c *gin.Context, name string) string {
+	route := strings.Split(c.FullPath(), "/")
+	segments := strings.Split(c.Request.URL.EscapedPath(), "/")
+	if len(route) == len(segments) {
+		for i, segment := range route {
+			if segment == ":"+name {
+				return segments[i]
+			}
+		}
+	}
+	// the route is not known, so the unescaped parameter is escaped again keeping its separators
+	values := strings.Split(c.Param(name), KeySeparator)
+	for i, value := range values {
+		values[i] = escapeKeyValue(value)
+	}
+	return strings.Join(values, KeySeparator)
+}
+
+// find loads the item with the given key that is visible for the request
+//
+// It returns an *HttpError with status 404 if the item does not exist
+func (self *CrudCtrl[T]) find(c *gin.Context, key Key, out *T) error {
+	return self.findIn(self.scope(c, self.Db), key, out)
+}
+
+// findIn loads the item with the given key using the provided query
+func (self *CrudCtrl[T]) findIn(db *gorm.DB, key Key, out *T) error {
+	err := db.Where(map[string]interface{}(key)).First(out).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		var probe T
+		_ = self.Keys.Apply(&probe, key)
+		return WrapHttpError(http.StatusNotFound, fmt.Errorf("%s %s was not found", self.ModelName, self.Keys.Format(&probe)))
+	}
+	return err
+}
+
 // Respond is a function creates a response based on the request headers, the data and the template
+//
+// The html templates receive the `Can` function as well, so they can check the authorizer, e.g. `{{if call $.Can "update" .}}`
+// and the `HasAction` function that checks if the action is exposed by the controller, e.g. `{{if call $.HasAction "delete"}}`.
+// The `CustomActions` of the controller are passed to render their buttons
 func (self *CrudCtrl[T]) Respond(c *gin.Context, data gin.H, templateName string) {
-    RespondWithConfig(200, c, data, templateName, self.Config)
+	self.respondWithStatus(c, http.StatusOK, data, templateName)
+}
+
+// respondWithStatus is Respond with a custom status
+func (self *CrudCtrl[T]) respondWithStatus(c *gin.Context, status int, data gin.H, templateName string) {
+	if negotiate(c, self.Config) == responseUI {
+		data["Can"] = self.canFunc(c)
+		data["HasAction"] = self.hasActionFunc()
+		data["CustomActions"] = self.customActions
+	}
+	RespondWithConfig(uint(status), c, data, templateName, self.Config)
 }
@@ -0,0 +1,78 @@
+package crudex
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReadOnlyActions are the actions of a controller that does not change its items
+var ReadOnlyActions = []Action{ActionList, ActionDetails, ActionHistory}
+
+// WithActions exposes only the given actions of the controller, e.g. `WithActions(ActionList, ActionCreate)`
+//
+// The routes of the disabled actions respond with 405 Method Not Allowed and the scaffolded templates hide their buttons.
+// By default every action is exposed, the custom actions are exposed only if they are listed as well, e.g. `Action("ship")`,
+// whether they are registered before or after. It replaces the actions of the previous calls of `WithActions` and `WithoutActions`
+func (self *CrudCtrl[T]) WithActions(actions ...Action) *CrudCtrl[T] {
+	self.actions = map[Action]bool{}
+	self.disabled = nil
+	for _, action := range actions {
+		self.actions[action] = true
+	}
+	return self
+}
+
+// WithoutActions disables the given actions of the controller, e.g. `WithoutActions(ActionDelete)`,
+// the rest of the actions stay exposed, including the custom actions registered later
+func (self *CrudCtrl[T]) WithoutActions(actions ...Action) *CrudCtrl[T] {
+	if self.disabled == nil {
+		self.disabled = map[Action]bool{}
+	}
+	for _, action := range actions {
+		self.disabled[action] = true
+	}
+	return self
+}
+
+// ReadOnly exposes only the ReadOnlyActions of the controller, e.g. for sql views or reference data
+func (self *CrudCtrl[T]) ReadOnly() *CrudCtrl[T] {
+	return self.WithActions(ReadOnlyActions...)
+}
+
+// HasAction returns true if the action is exposed by the controller
+func (self *CrudCtrl[T]) HasAction(action Action) bool {
+	if self.disabled[action] {
+		return false
+	}
+	if self.actions == nil {
+		return true
+	}
+	return self.actions[action]
+}
+
+// requireAction wraps the handler so it responds with 405 if none of the actions is exposed by the controller
+func (self *CrudCtrl[T]) requireAction(handler gin.HandlerFunc, actions ...Action) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, action := range actions {
+			if self.HasAction(action) {
+				handler(c)
+				return
+			}
+		}
+		self.fail(c, http.StatusMethodNotAllowed, notAllowed(actions[0], self.ModelName))
+	}
+}
+
+// notAllowed creates the error for an action that is not exposed by the controller
+func notAllowed(action Action, modelName string) error {
+	return NewHttpError(http.StatusMethodNotAllowed, fmt.Sprintf("%s is not allowed on %s", action, modelName))
+}
+
+// hasActionFunc returns the `HasAction` function of the templates, e.g. `{{if call $.HasAction "delete"}}`
+func (self *CrudCtrl[T]) hasActionFunc() func(action string) bool {
+	return func(action string) bool {
+		return self.HasAction(Action(action))
+	}
+}
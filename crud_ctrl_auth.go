@@ -0,0 +1,124 @@
+package crudex
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Action is the name of an operation of the CrudCtrl that is checked by the IAuthorizer
+type Action string
+
+const (
+	// ActionList lists the items, the item passed to the authorizer is nil
+	ActionList Action = "list"
+	// ActionDetails shows a single item
+	ActionDetails Action = "details"
+	// ActionCreate creates a new item, it is checked with a nil item before the body is bound and with the bound item before it is saved
+	ActionCreate Action = "create"
+	// ActionUpdate updates an existing item, it is checked with the current item before the body is bound
+	// and with the changed item before it is saved
+	ActionUpdate Action = "update"
+	// ActionDelete deletes an existing item
+	ActionDelete Action = "delete"
+)
+
+// IAuthorizer decides which actions the current user can do on the items of a CrudCtrl
+//
+// Unlike a middleware it sees the item that is being touched, so it can enforce row level permissions
+type IAuthorizer[T IEntity] interface {
+	// Authorize returns an error if the action is not allowed on the item
+	//
+	// The item is the one loaded from the database, it is nil for the list and for the create before the body is bound.
+	// The created and updated items are checked once more after they are bound, with the values that are going to be saved.
+	// If the error is not an *HttpError the client receives 403 Forbidden
+	Authorize(c *gin.Context, action Action, item *T) error
+
+	// Scope restricts the query to the items that are visible to the current user
+	//
+	// It is applied on the List query and on every query that loads an item by its key
+	Scope(c *gin.Context, db *gorm.DB) *gorm.DB
+}
+
+// Policy is an IAuthorizer built from functions, any of them can be left nil
+type Policy[T IEntity] struct {
+	AuthorizeFunc func(c *gin.Context, action Action, item *T) error
+	ScopeFunc     func(c *gin.Context, db *gorm.DB) *gorm.DB
+}
+
+// Authorize calls the AuthorizeFunc of the policy, every action is allowed if it is not set
+func (self *Policy[T]) Authorize(c *gin.Context, action Action, item *T) error {
+	if self.AuthorizeFunc == nil {
+		return nil
+	}
+	return self.AuthorizeFunc(c, action, item)
+}
+
+// Scope calls the ScopeFunc of the policy, every item is visible if it is not set
+func (self *Policy[T]) Scope(c *gin.Context, db *gorm.DB) *gorm.DB {
+	if self.ScopeFunc == nil {
+		return db
+	}
+	return self.ScopeFunc(c, db)
+}
+
+// WithAuthorizer sets the authorizer that is consulted before every action of the controller
+func (self *CrudCtrl[T]) WithAuthorizer(authorizer IAuthorizer[T]) *CrudCtrl[T] {
+	self.Authorizer = authorizer
+	return self
+}
+
+// authorize checks the action with the authorizer of the controller
+//
+// The actions that are not exposed by the controller fail with 405,
+// the errors that do not carry a status are wrapped in an *HttpError with status 403
+func (self *CrudCtrl[T]) authorize(c *gin.Context, action Action, item *T) error {
+	if !self.HasAction(action) {
+		return notAllowed(action, self.ModelName)
+	}
+	if self.Authorizer == nil {
+		return nil
+	}
+	if err := self.Authorizer.Authorize(c, action, item); err != nil {
+		if ErrorStatus(err, 0) == 0 {
+			return WrapHttpError(http.StatusForbidden, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// scope restricts the query to the items that are visible for the request,
+// the items of the tenant of the request that are allowed by the authorizer
+func (self *CrudCtrl[T]) scope(c *gin.Context, db *gorm.DB) *gorm.DB {
+	db = self.scopeTenant(c, db)
+	if self.Authorizer == nil {
+		return db
+	}
+	return self.Authorizer.Scope(c, db)
+}
+
+// Can returns true if the action is allowed on the item for the request
+//
+// The item can be either T or *T, for any other value the action is checked without an item
+func (self *CrudCtrl[T]) Can(c *gin.Context, action Action, item interface{}) bool {
+	var target *T
+	switch v := item.(type) {
+	case T:
+		target = &v
+	case *T:
+		target = v
+	}
+	if action == ActionHistory && self.auditReader() == nil {
+		return false
+	}
+	return self.authorize(c, action, target) == nil
+}
+
+// canFunc returns the `Can` function of the templates, e.g. `{{if call $.Can "update" .}}`
+func (self *CrudCtrl[T]) canFunc(c *gin.Context) func(action string, item interface{}) bool {
+	return func(action string, item interface{}) bool {
+		return self.Can(c, Action(action), item)
+	}
+}
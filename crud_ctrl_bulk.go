@@ -0,0 +1,338 @@
+package crudex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// BulkModeAtomic commits the bulk operation only if every item succeeds
+	BulkModeAtomic = "atomic"
+	// BulkModeContinue commits the items that succeed and reports the ones that failed
+	BulkModeContinue = "continue"
+)
+
+// BulkResult is the outcome of a single item of a bulk operation
+type BulkResult struct {
+	Index  int    `json:"index"`
+	Key    string `json:"key,omitempty"`
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// Errors are the field errors of the item if it is not valid
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// BulkReport is the per item report of a bulk operation
+type BulkReport struct {
+	Mode string `json:"mode"`
+	// DryRun is true if the items were only checked, nothing is committed in a dry run
+	DryRun    bool         `json:"dryRun,omitempty"`
+	Committed bool         `json:"committed"`
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+	Results   []BulkResult `json:"results"`
+}
+
+func (self *BulkReport) add(result BulkResult) {
+	if result.Error == "" {
+		self.Succeeded++
+	} else {
+		self.Failed++
+	}
+	self.Results = append(self.Results, result)
+}
+
+// bulkOp processes a single item of a bulk operation inside the transaction
+type bulkOp func(tx *gorm.DB, index int) BulkResult
+
+// bulkNext advances to the item of the index, it returns false after the last item
+//
+// The items of an import are read from the file one by one, an error fails the whole operation
+type bulkNext func(index int) (bool, error)
+
+// bulkCount returns the bulkNext of the given number of items that are already read
+func bulkCount(count int) bulkNext {
+	return func(index int) (bool, error) {
+		return index < count, nil
+	}
+}
+
+// BulkCreate is a handler that creates all the items in the json array of the request body
+// it is a PUT request
+//
+// See `runBulk` for the modes of the operation and the response
+func (self *CrudCtrl[T]) BulkCreate(c *gin.Context) {
+	self.bulkSave(c, true)
+}
+
+// BulkUpsert is a handler that saves all the items in the json array of the request body,
+// the items that have a key are updated and the ones that do not are created
+// it is a POST request
+//
+// See `runBulk` for the modes of the operation and the response
+func (self *CrudCtrl[T]) BulkUpsert(c *gin.Context) {
+	self.bulkSave(c, false)
+}
+
+func (self *CrudCtrl[T]) bulkSave(c *gin.Context, create bool) {
+	var raw []json.RawMessage
+	if err := c.ShouldBindJSON(&raw); err != nil {
+		self.fail(c, http.StatusBadRequest, err)
+		return
+	}
+	self.saveAll(c, create, bulkCount(len(raw)), "", func(index int, item *T) error {
+		return jsonValidationError(json.Unmarshal(raw[index], item))
+	})
+}
+
+// saveAll saves the decoded items with runBulk, the template is used to render the report for the UI requests
+func (self *CrudCtrl[T]) saveAll(c *gin.Context, create bool, next bulkNext, template string, decode func(index int, item *T) error) {
+	changes := []Change[T]{}
+	report := self.runBulk(c, next, template, func(tx *gorm.DB, index int) BulkResult {
+		var item T
+		if err := self.Hooks.run(c, self.Hooks.BeforeBind, &item); err != nil {
+			return bulkFailure(index, "", http.StatusBadRequest, err)
+		}
+		if err := decode(index, &item); err != nil {
+			return bulkFailure(index, "", http.StatusBadRequest, err)
+		}
+		if err := self.Hooks.run(c, self.Hooks.AfterBind, &item); err != nil {
+			return bulkFailure(index, "", http.StatusBadRequest, err)
+		}
+		if err := self.stampTenant(c, &item); err != nil {
+			return bulkFailure(index, "", http.StatusForbidden, err)
+		}
+		current, err := self.authorizeBulkSave(c, tx, create, &item)
+		if err != nil {
+			return bulkFailure(index, "", http.StatusForbidden, err)
+		}
+		if err := self.Validate(c, &item); err != nil {
+			return bulkFailure(index, "", http.StatusUnprocessableEntity, err)
+		}
+		if err := self.Hooks.run(c, self.Hooks.BeforeSave, &item); err != nil {
+			return bulkFailure(index, "", http.StatusBadRequest, err)
+		}
+		if current == nil {
+			if err := tx.Create(&item).Error; err != nil {
+				return bulkFailure(index, "", http.StatusInternalServerError, err)
+			}
+		} else {
+			db, err := self.whereItemVersion(tx, current, &item)
+			if err != nil {
+				return bulkFailure(index, self.Keys.Format(&item), http.StatusPreconditionFailed, err)
+			}
+			// every column is selected so gorm does not insert the item when the update does not match it
+			if err := self.checkItemWritten(db.Select("*").Save(&item), &item); err != nil {
+				return bulkFailure(index, self.Keys.Format(&item), http.StatusInternalServerError, err)
+			}
+		}
+		if err := self.Hooks.run(c, self.Hooks.AfterSave, &item); err != nil {
+			return bulkFailure(index, self.Keys.Format(&item), http.StatusInternalServerError, err)
+		}
+		change := Change[T]{Action: ActionCreate, Key: self.Keys.Format(&item), Before: current, After: &item}
+		if current != nil {
+			change.Action = ActionUpdate
+		}
+		if err := self.enqueueWebhooks(c, tx, change); err != nil {
+			return bulkFailure(index, change.Key, http.StatusInternalServerError, err)
+		}
+		changes = append(changes, change)
+		return BulkResult{Index: index, Key: change.Key, Status: http.StatusOK}
+	})
+	self.notifyBulk(c, report, changes)
+}
+
+// authorizeBulkSave authorizes the item of a bulk save, the items that have an existing key are checked as updates
+//
+// The updates are authorized with the current version of the item and with the submitted one, the new items with the submitted one.
+// It returns the current version of the item if it is an update, or nil if the item is created
+func (self *CrudCtrl[T]) authorizeBulkSave(c *gin.Context, tx *gorm.DB, create bool, item *T) (*T, error) {
+	if !create {
+		key, err := self.Keys.Parse(self.Keys.Format(item))
+		if err == nil {
+			current := new(T)
+			err := self.findIn(self.scope(c, tx), key, current)
+			if err == nil {
+				if err := self.authorize(c, ActionUpdate, current); err != nil {
+					return current, err
+				}
+				return current, self.authorize(c, ActionUpdate, item)
+			}
+			if ErrorStatus(err, 0) != http.StatusNotFound {
+				return nil, err
+			}
+			// an item that exists but is not visible must not be overwritten
+			if self.findIn(tx, key, current) == nil {
+				return nil, err
+			}
+		}
+	}
+	return nil, self.authorize(c, ActionCreate, item)
+}
+
+// BulkDelete is a handler that deletes all the items with the given keys
+// it is a DELETE request
+//
+// The keys are read from the json array of the request body, or from the `id` query parameters
+// (htmx sends the parameters of DELETE requests in the url).
+// See `runBulk` for the modes of the operation and the response
+func (self *CrudCtrl[T]) BulkDelete(c *gin.Context) {
+	ids := c.QueryArray("id")
+	if len(ids) == 0 && c.ContentType() == gin.MIMEJSON {
+		var raw []interface{}
+		if err := c.ShouldBindJSON(&raw); err != nil {
+			self.fail(c, http.StatusBadRequest, err)
+			return
+		}
+		for _, id := range raw {
+			ids = append(ids, fmt.Sprint(id))
+		}
+	}
+	changes := []Change[T]{}
+	report := self.runBulk(c, bulkCount(len(ids)), "", func(tx *gorm.DB, index int) BulkResult {
+		key, err := self.Keys.Parse(ids[index])
+		if err != nil {
+			return bulkFailure(index, ids[index], http.StatusBadRequest, err)
+		}
+		var item T
+		if err := self.findIn(self.scope(c, tx), key, &item); err != nil {
+			return bulkFailure(index, ids[index], http.StatusInternalServerError, err)
+		}
+		if err := self.authorize(c, ActionDelete, &item); err != nil {
+			return bulkFailure(index, ids[index], http.StatusForbidden, err)
+		}
+		if err := self.Hooks.run(c, self.Hooks.BeforeDelete, &item); err != nil {
+			return bulkFailure(index, ids[index], http.StatusBadRequest, err)
+		}
+		if self.options().HardDelete() {
+			tx = tx.Unscoped()
+		}
+		if err := tx.Delete(&item).Error; err != nil {
+			return bulkFailure(index, ids[index], http.StatusInternalServerError, err)
+		}
+		if err := self.Hooks.run(c, self.Hooks.AfterDelete, &item); err != nil {
+			return bulkFailure(index, ids[index], http.StatusInternalServerError, err)
+		}
+		change := Change[T]{Action: ActionDelete, Key: self.Keys.Format(&item), Before: &item}
+		if err := self.enqueueWebhooks(c, tx, change); err != nil {
+			return bulkFailure(index, ids[index], http.StatusInternalServerError, err)
+		}
+		changes = append(changes, change)
+		return BulkResult{Index: index, Key: ids[index], Status: http.StatusOK}
+	})
+	self.notifyBulk(c, report, changes)
+}
+
+// notifyBulk notifies the changes of a bulk operation once they are committed, their webhooks are queued in the transaction of the operation
+func (self *CrudCtrl[T]) notifyBulk(c *gin.Context, report *BulkReport, changes []Change[T]) {
+	if report == nil || !report.Committed {
+		return
+	}
+	if len(changes) > 0 {
+		self.webhooksCommitted()
+	}
+	for _, change := range changes {
+		self.notify(c, change)
+	}
+}
+
+// errDryRun rolls back the transaction of a dry run
+var errDryRun = errors.New("dry run")
+
+// runBulk runs the operation for every item inside a single transaction and responds with the BulkReport
+//
+// The mode is selected with the `mode` query parameter:
+//   - atomic(default): if any item fails the whole transaction is rolled back and the response status is 422
+//   - continue: the failed items are rolled back and the rest are committed
+//
+// Every item runs in its own savepoint, so a failed item does not abort the transaction(e.g. on Postgres) and the report
+// of the next items is accurate in every mode.
+//
+// With `dry_run=true` every item is processed and reported the same way, but the transaction is always rolled back.
+// If a template is given the UI requests get it rendered with the `BulkReport`, otherwise they are redirected to the list.
+// It returns the report, or nil if the operation failed before it was started or the items could not be read
+func (self *CrudCtrl[T]) runBulk(c *gin.Context, next bulkNext, template string, op bulkOp) *BulkReport {
+	mode := c.DefaultQuery("mode", BulkModeAtomic)
+	if mode != BulkModeAtomic && mode != BulkModeContinue {
+		self.fail(c, http.StatusBadRequest, NewHttpError(http.StatusBadRequest, fmt.Sprintf("Invalid bulk mode: %s", mode)))
+		return nil
+	}
+	if err := self.checkAuditTenant(c); err != nil {
+		self.fail(c, http.StatusForbidden, err)
+		return nil
+	}
+	report := &BulkReport{Mode: mode, DryRun: c.Query("dry_run") == "true", Results: []BulkResult{}}
+	var readErr error
+	err := self.Db.Transaction(func(tx *gorm.DB) error {
+		for i := 0; ; i++ {
+			more, err := next(i)
+			if err != nil {
+				readErr = err
+				return err
+			}
+			if !more {
+				break
+			}
+			savepoint := fmt.Sprintf("bulk_%d", i)
+			if err := tx.SavePoint(savepoint).Error; err != nil {
+				return err
+			}
+			result := op(tx, i)
+			report.add(result)
+			if result.Error != "" {
+				if err := tx.RollbackTo(savepoint).Error; err != nil {
+					return err
+				}
+			}
+		}
+		if report.Failed > 0 && mode == BulkModeAtomic {
+			return NewHttpError(http.StatusUnprocessableEntity, fmt.Sprintf("%d of %d items failed", report.Failed, len(report.Results)))
+		}
+		if report.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if readErr != nil {
+		self.fail(c, http.StatusBadRequest, readErr)
+		return nil
+	}
+	if err != nil && !errors.Is(err, errDryRun) && report.Failed == 0 {
+		self.fail(c, http.StatusInternalServerError, err)
+		return nil
+	}
+	report.Committed = err == nil
+	status := http.StatusOK
+	if report.Failed > 0 && mode == BulkModeAtomic {
+		status = http.StatusUnprocessableEntity
+	}
+	if negotiate(c, self.Config) == responseUI {
+		if template != "" {
+			self.respondWithStatus(c, status, gin.H{"BulkReport": report, "Path": self.BasePath()}, template)
+			return report
+		}
+		if status != http.StatusOK {
+			self.fail(c, status, err)
+			return report
+		}
+	}
+	if !report.DryRun {
+		c.Header("HX-Redirect", self.BasePath())
+	}
+	c.JSON(status, report)
+	return report
+}
+
+func bulkFailure(index int, key string, status int, err error) BulkResult {
+	result := BulkResult{Index: index, Key: key, Status: ErrorStatus(err, status), Error: err.Error()}
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) && len(validationErr.Errors) > 0 {
+		result.Errors = validationErr.Errors
+	}
+	return result
+}
@@ -0,0 +1,62 @@
+package crudex
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// ActionRestore restores a soft deleted item, it is authorized as ActionUpdate
+	ActionRestore Action = "restore"
+	// ActionPurge permanently deletes an item, it is authorized as ActionDelete
+	ActionPurge Action = "purge"
+)
+
+// Change describes a write of an item that was done through the controller
+type Change[T IEntity] struct {
+	// Action is the kind of the change, e.g. ActionCreate
+	Action Action
+	// Key is the route key of the changed item
+	Key string
+	// Before is the item before the change, it is nil for the created items
+	Before *T
+	// After is the item after the change, it is nil for the deleted items
+	After *T
+}
+
+// commit runs the write in a transaction and queues its change for the webhooks in the same transaction,
+// so the change is queued only if it is saved. The write returns nil if nothing was changed.
+// Nothing is written if the change can not be audited with the tenant of the request, see `checkAuditTenant`
+//
+// It returns the committed change, the side effects outside of the database are dispatched with `notify` after it
+func (self *CrudCtrl[T]) commit(c *gin.Context, write func(tx *gorm.DB) (*Change[T], error)) (*Change[T], error) {
+	if err := self.checkAuditTenant(c); err != nil {
+		return nil, err
+	}
+	var change *Change[T]
+	err := self.Db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if change, err = write(tx); err != nil || change == nil {
+			return err
+		}
+		return self.enqueueWebhooks(c, tx, *change)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if change != nil {
+		self.webhooksCommitted()
+	}
+	return change, nil
+}
+
+// notify is invoked after every change that is committed in the database
+//
+// It is the single place where the side effects of the writes are dispatched, the webhooks are queued by `commit`
+func (self *CrudCtrl[T]) notify(c *gin.Context, change Change[T]) {
+	self.audit(c, change)
+	self.publish(c, change)
+	if self.stream != nil {
+		self.stream.publish(change)
+	}
+}
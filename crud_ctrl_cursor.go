@@ -0,0 +1,160 @@
+package crudex
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// CursorParam is the query parameter of the cursor of the keyset paging, e.g. `?$cursor=eyJrIjpb...`
+const CursorParam = "$cursor"
+
+// cursor is the position of a keyset page, the values of the sort fields of the last item of the previous page
+type cursor struct {
+	// Keys are the sorted columns with their direction, so a cursor of another order is rejected
+	Keys   []string          `json:"k"`
+	Values []json.RawMessage `json:"v"`
+}
+
+// sortKey is a field of the keyset order
+type sortKey struct {
+	field *schema.Field
+	desc  bool
+}
+
+func (self sortKey) String() string {
+	if self.desc {
+		return fmt.Sprintf("%s desc", self.field.DBName)
+	}
+	return fmt.Sprintf("%s asc", self.field.DBName)
+}
+
+// WithCursorPaging pages the list with cursors by default, instead of `$skip`
+//
+// With the keyset paging the next page is loaded after the last item of the previous page, its speed does not depend on the position
+// in the table, so it fits large tables. The lists are paged with cursors as well when the `$cursor` query parameter is present
+func (self *CrudCtrl[T]) WithCursorPaging(enabled bool) *CrudCtrl[T] {
+	self.cursorPaging = enabled
+	return self
+}
+
+// sortKeys returns the order of the keyset paging, the fields of `$orderby` followed by the primary key fields
+// that are not ordered yet, so the order is unique
+func (self *CrudCtrl[T]) sortKeys(c *gin.Context) (keys []sortKey, ordered int, err error) {
+	sch := self.Schema()
+	seen := map[*schema.Field]bool{}
+	for _, item := range strings.Split(c.Query("$orderby"), ",") {
+		parts := strings.Fields(item)
+		if len(parts) == 0 {
+			continue
+		}
+		field := sch.LookUpField(parts[0])
+		if field == nil || field.DBName == "" {
+			return nil, 0, NewHttpError(http.StatusBadRequest, fmt.Sprintf("Invalid $orderby: %s has no field %s", sch.Name, parts[0]))
+		}
+		keys = append(keys, sortKey{field: field, desc: len(parts) > 1 && strings.EqualFold(parts[1], "desc")})
+		seen[field] = true
+	}
+	ordered = len(keys)
+	for _, field := range sch.PrimaryFields {
+		if !seen[field] {
+			keys = append(keys, sortKey{field: field})
+		}
+	}
+	return keys, ordered, nil
+}
+
+// keyset applies the keyset paging on the query, the items are sorted by the sort keys and start after the `$cursor`
+//
+// The sort fields should not be null, the items with a null value are skipped after the first page. A cursor that can not be decoded or that was created for another order is reported as an *HttpError with status 400
+func (self *CrudCtrl[T]) keyset(c *gin.Context, db *gorm.DB, page *Page, projection *Projection) (*gorm.DB, error) {
+	keys, ordered, err := self.sortKeys(c)
+	if err != nil {
+		return nil, err
+	}
+	sch := self.Schema()
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = key.String()
+		if projection != nil && !slices.Contains(projection.Columns, key.field.DBName) {
+			return nil, NewHttpError(http.StatusBadRequest, fmt.Sprintf("Invalid $select: the sort field %s has to be selected", key.field.Name))
+		}
+		if i >= ordered {
+			// the fields of $orderby are already sorted
+			db = db.Order(clause.OrderByColumn{Column: clause.Column{Table: sch.Table, Name: key.field.DBName}, Desc: key.desc})
+		}
+	}
+	page.Keyset, page.Skip, page.HasPrev, page.keys = true, 0, false, keys
+	db = db.Offset(-1)
+
+	encoded := c.Query(CursorParam)
+	if encoded == "" {
+		return db, nil
+	}
+	invalid := NewHttpError(http.StatusBadRequest, "Invalid $cursor")
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, invalid
+	}
+	var position cursor
+	if err := json.Unmarshal(raw, &position); err != nil || len(position.Values) != len(keys) {
+		return nil, invalid
+	}
+	if !slices.Equal(position.Keys, names) {
+		return nil, NewHttpError(http.StatusBadRequest, "Invalid $cursor: the order of the list has changed")
+	}
+	values := make([]interface{}, len(keys))
+	for i, key := range keys {
+		value := reflect.New(key.field.FieldType)
+		if err := json.Unmarshal(position.Values[i], value.Interface()); err != nil {
+			return nil, invalid
+		}
+		values[i] = value.Elem().Interface()
+	}
+
+	// (a > va) OR (a = va AND b > vb) OR (a = va AND b = vb AND id > vid)
+	after := make([]clause.Expression, len(keys))
+	for i, key := range keys {
+		conditions := []clause.Expression{}
+		for j := 0; j < i; j++ {
+			conditions = append(conditions, clause.Eq{Column: clause.Column{Table: sch.Table, Name: keys[j].field.DBName}, Value: values[j]})
+		}
+		column := clause.Column{Table: sch.Table, Name: key.field.DBName}
+		if key.desc {
+			conditions = append(conditions, clause.Lt{Column: column, Value: values[i]})
+		} else {
+			conditions = append(conditions, clause.Gt{Column: column, Value: values[i]})
+		}
+		after[i] = clause.And(conditions...)
+	}
+	page.Cursor = encoded
+	return db.Where(clause.Or(after...)), nil
+}
+
+// encodeCursor returns the cursor of the page that starts after the item
+func encodeCursor(item interface{}, keys []sortKey) (string, error) {
+	position := cursor{Keys: make([]string, len(keys)), Values: make([]json.RawMessage, len(keys))}
+	for i, key := range keys {
+		value, _ := key.field.ValueOf(context.Background(), reflect.Indirect(reflect.ValueOf(item)))
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		position.Keys[i], position.Values[i] = key.String(), raw
+	}
+	raw, err := json.Marshal(position)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
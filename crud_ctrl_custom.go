@@ -0,0 +1,110 @@
+package crudex
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CustomAction describes a named action that is added next to the CRUD routes of a controller,
+// e.g. `POST /orders/:id/ship` or `POST /cars/recalculate`
+//
+// The scaffolded list and detail templates show a button for every custom action
+// and the generated API description lists their routes
+type CustomAction struct {
+	// Name is the last segment of the route and the action that is checked by the authorizer
+	Name string
+	// Label is the text of the button, it defaults to the Name
+	Label string
+	// Method is the http method of the route, it defaults to POST
+	Method string
+	// Member actions are invoked on a single item(`/:id/name`), the others on the collection(`/name`)
+	Member bool
+	// Confirm is the prompt that is shown before the action is invoked, no prompt is shown if it is empty
+	Confirm string
+}
+
+// Verb returns the lowercase http method of the action, e.g. `{{range $.CustomActions}}<button hx-{{.Verb}}="...">{{end}}`
+func (self CustomAction) Verb() string {
+	return strings.ToLower(self.Method)
+}
+
+// Route returns the route of the action relative to the base path of the controller
+func (self CustomAction) Route() string {
+	if self.Member {
+		return fmt.Sprintf("/:id/%s", self.Name)
+	}
+	return fmt.Sprintf("/%s", self.Name)
+}
+
+// WithCustomAction registers a custom action on the controller
+//
+// The handler receives the item loaded by the key of the route for the member actions and nil for the collection ones.
+// The action is authorized as `Action(action.Name)` before the handler is called.
+// If the actions of the controller are limited with `WithActions` the action must be listed there to be exposed.
+// If the handler does not write a response, the client is redirected to the item or to the list
+func (self *CrudCtrl[T]) WithCustomAction(action CustomAction, handler Hook[T]) *CrudCtrl[T] {
+	if action.Name == "" {
+		panic("The custom action needs a name")
+	}
+	if action.Label == "" {
+		action.Label = action.Name
+	}
+	action.Method = strings.ToUpper(action.Method)
+	if action.Method == "" {
+		action.Method = http.MethodPost
+	}
+	self.customActions = append(self.customActions, action)
+	self.customHandlers = append(self.customHandlers, handler)
+	if self.Router != nil {
+		self.enableCustomAction(self.Router, action, handler)
+	}
+	return self
+}
+
+// CustomActions returns the custom actions of the controller in the order they were registered
+func (self *CrudCtrl[T]) CustomActions() []CustomAction {
+	return self.customActions
+}
+
+// enableCustomAction registers the route of the custom action
+func (self *CrudCtrl[T]) enableCustomAction(r IRouter, action CustomAction, handler Hook[T]) {
+	r.Handle(action.Method, action.Route(), self.requireAction(self.customActionHandler(action, handler), Action(action.Name)))
+}
+
+// customActionHandler creates the handler that loads and authorizes the item of the action before calling the handler
+func (self *CrudCtrl[T]) customActionHandler(action CustomAction, handler Hook[T]) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var item *T
+		redirect := self.BasePath()
+		if action.Member {
+			key, err := self.parseKey(c)
+			if err != nil {
+				self.fail(c, http.StatusBadRequest, err)
+				return
+			}
+			item = new(T)
+			if err := self.find(c, key, item); err != nil {
+				self.fail(c, http.StatusInternalServerError, err)
+				return
+			}
+			redirect = fmt.Sprintf("%s/%s", self.BasePath(), self.Keys.Format(item))
+		}
+		if err := self.authorize(c, Action(action.Name), item); err != nil {
+			self.fail(c, http.StatusForbidden, err)
+			return
+		}
+		if err := handler(c, item); err != nil {
+			self.fail(c, http.StatusBadRequest, err)
+			return
+		}
+		if c.Writer.Written() {
+			return
+		}
+		c.Header("HX-Redirect", redirect)
+		c.String(http.StatusOK, "Done")
+		c.Abort()
+	}
+}
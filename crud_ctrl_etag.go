@@ -0,0 +1,154 @@
+package crudex
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ETagFormField is the name of the form field that carries the version of the item in the scaffolded forms
+//
+// It is checked the same way as the If-Match header, so that forms can not silently overwrite a newer version of the item
+const ETagFormField = "_etag"
+
+// ETag returns the entity tag of the item
+//
+// The tag is built from the version field of the model, the auto update time field(the UpdatedAt field of gorm.Model).
+// The times are used with their full precision, so two writes in the same millisecond get different tags.
+// An empty string is returned if the model has no such field
+func (self *CrudCtrl[T]) ETag(item *T) string {
+	field := self.versionField()
+	if field == nil {
+		return ""
+	}
+	value, _ := field.ValueOf(context.Background(), reflect.ValueOf(item).Elem())
+	switch v := value.(type) {
+	case time.Time:
+		return fmt.Sprintf(`"%x"`, v.UnixNano())
+	case *time.Time:
+		if v != nil {
+			return fmt.Sprintf(`"%x"`, v.UnixNano())
+		}
+	case int64, int, uint64, uint:
+		return fmt.Sprintf(`"%x"`, v)
+	}
+	return ""
+}
+
+// versionField returns the field the ETag is built from, or nil if the model has no auto update time field
+func (self *CrudCtrl[T]) versionField() *schema.Field {
+	for _, field := range self.Schema().Fields {
+		if field.AutoUpdateTime > 0 && field.DBName != "" {
+			return field
+		}
+	}
+	return nil
+}
+
+// CheckPrecondition verifies the If-Match header(or the `_etag` form field) of the request against the current version of the item
+//
+// It returns an *HttpError with status 412 if the item was changed since the client has read it.
+// Requests without a precondition are always accepted
+func (self *CrudCtrl[T]) CheckPrecondition(c *gin.Context, current *T) error {
+	ifMatch := requestETag(c)
+	if ifMatch == "" {
+		return nil
+	}
+	etag := self.ETag(current)
+	for _, tag := range strings.Split(ifMatch, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || (etag != "" && tag == etag) {
+			return nil
+		}
+	}
+	return NewHttpError(http.StatusPreconditionFailed, fmt.Sprintf("%s was changed by someone else, reload it and try again", self.ModelName))
+}
+
+// requestETag returns the version the client expects, either from the If-Match header or from the `_etag` form field
+//
+// The query string is checked as well since htmx sends the parameters of DELETE requests in the url
+func requestETag(c *gin.Context) string {
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" {
+		return ifMatch
+	}
+	switch c.ContentType() {
+	case gin.MIMEPOSTForm, gin.MIMEMultipartPOSTForm:
+		if value := c.PostForm(ETagFormField); value != "" {
+			return value
+		}
+	}
+	return c.Query(ETagFormField)
+}
+
+// whereVersion makes the write of the item conditional on the version that was checked by CheckPrecondition
+//
+// The write does not match any row if the item was changed after it was loaded, see `checkWritten`.
+// The writes of the requests without a precondition are not conditional
+func (self *CrudCtrl[T]) whereVersion(c *gin.Context, db *gorm.DB, current *T) *gorm.DB {
+	field := self.versionField()
+	if field == nil || current == nil || requestETag(c) == "" {
+		return db
+	}
+	value, _ := field.ValueOf(c, reflect.ValueOf(current).Elem())
+	return db.Where(clause.Eq{Column: clause.Column{Table: self.Schema().Table, Name: field.DBName}, Value: value})
+}
+
+// checkWritten returns the error of the write of the item
+//
+// A write that did not match any row fails with an *HttpError, with status 412 if the item was changed after it was loaded
+// (see `whereVersion`) or with status 404 if it was deleted in the meantime
+func (self *CrudCtrl[T]) checkWritten(c *gin.Context, result *gorm.DB, item *T) error {
+	if result.Error != nil || result.RowsAffected > 0 {
+		return result.Error
+	}
+	if requestETag(c) != "" && self.versionField() != nil {
+		return NewHttpError(http.StatusPreconditionFailed, fmt.Sprintf("%s was changed by someone else, reload it and try again", self.ModelName))
+	}
+	return NewHttpError(http.StatusNotFound, fmt.Sprintf("%s %s was not found", self.ModelName, self.Keys.Format(item)))
+}
+
+// refreshVersion reloads the version of the saved item, so its ETag matches the value stored by the database
+// even if the database keeps the times with a lower precision. The item keeps its version if it can not be reloaded
+func (self *CrudCtrl[T]) refreshVersion(db *gorm.DB, item *T) {
+	if field := self.versionField(); field != nil {
+		_ = db.Select(field.DBName).Take(item).Error
+	}
+}
+
+// whereItemVersion makes the bulk write of the item conditional on the version that the item carries,
+// the same way `whereVersion` does with the If-Match header of the single item routes
+//
+// It returns an *HttpError with status 412 if the item carries a version that is not the current one.
+// The items without a version are not checked
+func (self *CrudCtrl[T]) whereItemVersion(db *gorm.DB, current *T, item *T) (*gorm.DB, error) {
+	field := self.versionField()
+	if field == nil {
+		return db, nil
+	}
+	if _, zero := field.ValueOf(context.Background(), reflect.ValueOf(item).Elem()); zero {
+		return db, nil
+	}
+	if self.ETag(item) != self.ETag(current) {
+		return nil, NewHttpError(http.StatusPreconditionFailed, fmt.Sprintf("%s was changed by someone else, reload it and try again", self.ModelName))
+	}
+	value, _ := field.ValueOf(context.Background(), reflect.ValueOf(current).Elem())
+	return db.Where(clause.Eq{Column: clause.Column{Table: self.Schema().Table, Name: field.DBName}, Value: value}), nil
+}
+
+// checkItemWritten returns the error of the bulk write of the item, see `checkWritten`
+//
+// A write that did not match any row fails with status 412, the item was changed or deleted after it was loaded
+func (self *CrudCtrl[T]) checkItemWritten(result *gorm.DB, item *T) error {
+	if result.Error != nil || result.RowsAffected > 0 {
+		return result.Error
+	}
+	return NewHttpError(http.StatusPreconditionFailed, fmt.Sprintf("%s %s was changed by someone else, reload it and try again", self.ModelName, self.Keys.Format(item)))
+}
@@ -0,0 +1,352 @@
+package crudex
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	odata "github.com/pboyd04/godata"
+	"gorm.io/gorm/schema"
+)
+
+// ExportBatchSize is the number of rows that are written to the client before the response is flushed
+var ExportBatchSize = 500
+
+// Export formats that are supported by the `/export` route, selected with the `format` query parameter
+const (
+	ExportCSV    = "csv"
+	ExportNDJSON = "ndjson"
+	ExportXLSX   = "xlsx"
+)
+
+// exportWriter writes the exported rows in one of the export formats
+type exportWriter interface {
+	// Header writes the names of the exported columns
+	Header(names []string) error
+	// Row writes a single item, values are the values of the columns and item is the (projected) item for the json formats
+	Row(values []interface{}, item interface{}) error
+	// Flush flushes the buffered rows to the response
+	Flush() error
+	// Close writes the end of the document
+	Close() error
+}
+
+// Export is a handler that streams the items of the model as CSV, NDJSON or XLSX
+// it is a GET request, the format is selected with the `format` query parameter and defaults to CSV
+//
+// It applies the same OData `$filter`, `$orderby`, `$select`, `q` search and simple filters as the List handler, but the rows are read with a cursor
+// and written to the client in batches of ExportBatchSize, so the whole list is never loaded in memory.
+// The columns are the fields of the model, or the selected ones
+func (self *CrudCtrl[T]) Export(c *gin.Context) {
+	if err := self.authorize(c, ActionList, nil); err != nil {
+		self.fail(c, http.StatusForbidden, err)
+		return
+	}
+	format := strings.ToLower(c.DefaultQuery("format", ExportCSV))
+	contentType, ok := map[string]string{
+		ExportCSV:    "text/csv; charset=utf-8",
+		ExportNDJSON: "application/x-ndjson",
+		ExportXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	}[format]
+	if !ok {
+		self.fail(c, http.StatusBadRequest, NewHttpError(http.StatusBadRequest, fmt.Sprintf("Unsupported export format: %s", format)))
+		return
+	}
+	dbRes, err := odata.GetGormSettingsFromGin(c, self.scope(c, self.Db))
+	if err != nil {
+		self.fail(c, http.StatusBadRequest, err)
+		return
+	}
+	if dbRes, err = self.Search(c, dbRes); err != nil {
+		self.fail(c, http.StatusBadRequest, err)
+		return
+	}
+	if dbRes, err = self.Filter(c, dbRes); err != nil {
+		self.fail(c, http.StatusBadRequest, err)
+		return
+	}
+	projection, err := self.Selection(c)
+	if err != nil {
+		self.fail(c, http.StatusBadRequest, err)
+		return
+	}
+	fields := self.exportFields()
+	if projection != nil {
+		fields = projection.Fields
+	}
+	rows, err := projection.Apply(dbRes).Model(new(T)).Rows()
+	if err != nil {
+		self.fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	defer rows.Close()
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, strings.ToLower(self.ModelName), format))
+	c.Status(http.StatusOK)
+	var out exportWriter
+	switch format {
+	case ExportNDJSON:
+		out = newNDJSONExport(c.Writer)
+	case ExportXLSX:
+		out = newXLSXExport(c.Writer)
+	default:
+		out = newCSVExport(c.Writer)
+	}
+
+	// the response is already started, so the errors can only be logged
+	failed := func(err error) {
+		_ = c.Error(err)
+		slog.Error("Failed to export", slog.String("model", self.ModelName), slog.Any("error", err))
+	}
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		names[i] = field.Name
+	}
+	if err := out.Header(names); err != nil {
+		failed(err)
+		return
+	}
+	for count := 1; rows.Next(); count++ {
+		var item T
+		if err := self.Db.ScanRows(rows, &item); err != nil {
+			failed(err)
+			return
+		}
+		values := make([]interface{}, len(fields))
+		for i, field := range fields {
+			values[i], _ = field.ValueOf(context.Background(), reflect.ValueOf(&item).Elem())
+		}
+		var row interface{} = &item
+		if projection != nil {
+			row = projection.Project(&item)
+		}
+		if err := out.Row(values, row); err != nil {
+			failed(err)
+			return
+		}
+		if count%ExportBatchSize == 0 {
+			if err := out.Flush(); err != nil {
+				failed(err)
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+	if err := rows.Err(); err != nil {
+		failed(err)
+		return
+	}
+	if err := out.Close(); err != nil {
+		failed(err)
+	}
+}
+
+// exportFields returns the fields of the model that are exported, the database fields that are not hidden from json
+func (self *CrudCtrl[T]) exportFields() []*schema.Field {
+	fields := []*schema.Field{}
+	for _, field := range self.Schema().Fields {
+		if field.DBName != "" && field.Tag.Get("json") != "-" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// formulaPrefixes are the first characters of the text that is escaped by escapeFormula
+const formulaPrefixes = "=+-@\t\r'"
+
+// escapeFormula prefixes the text that a spreadsheet would run as a formula with a quote,
+// the text starting with `=`, `+`, `-`, `@`, tab or carriage return. The text that starts with a quote
+// is prefixed as well, so unescapeFormula gives back the original text
+func escapeFormula(text string) string {
+	if text != "" && strings.ContainsRune(formulaPrefixes, rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+// unescapeFormula removes the quote that escapeFormula adds, so an exported file can be imported back
+func unescapeFormula(text string) string {
+	if len(text) > 1 && text[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(text[1])) {
+		return text[1:]
+	}
+	return text
+}
+
+// csvExport writes the rows as comma separated values with a header row
+type csvExport struct {
+	w *csv.Writer
+}
+
+func newCSVExport(w io.Writer) *csvExport {
+	return &csvExport{w: csv.NewWriter(w)}
+}
+
+func (self *csvExport) Header(names []string) error {
+	return self.w.Write(names)
+}
+
+func (self *csvExport) Row(values []interface{}, item interface{}) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		switch v := formatValue(value).(type) {
+		case nil:
+		case string:
+			record[i] = escapeFormula(v)
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+	return self.w.Write(record)
+}
+
+func (self *csvExport) Flush() error {
+	self.w.Flush()
+	return self.w.Error()
+}
+
+func (self *csvExport) Close() error {
+	return self.Flush()
+}
+
+// ndjsonExport writes every item as a json object on its own line, it respects the json tags of the model
+type ndjsonExport struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func newNDJSONExport(w io.Writer) *ndjsonExport {
+	buf := bufio.NewWriter(w)
+	return &ndjsonExport{buf: buf, enc: json.NewEncoder(buf)}
+}
+
+func (self *ndjsonExport) Header(names []string) error {
+	return nil
+}
+
+func (self *ndjsonExport) Row(values []interface{}, item interface{}) error {
+	return self.enc.Encode(item)
+}
+
+func (self *ndjsonExport) Flush() error {
+	return self.buf.Flush()
+}
+
+func (self *ndjsonExport) Close() error {
+	return self.Flush()
+}
+
+// xlsxExport writes a workbook with a single sheet, the sheet is streamed in the zip archive as the rows are read
+type xlsxExport struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+}
+
+// xlsxParts are the static parts of the workbook
+var xlsxParts = []struct{ name, content string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+}
+
+func newXLSXExport(w io.Writer) *xlsxExport {
+	return &xlsxExport{zip: zip.NewWriter(w)}
+}
+
+func (self *xlsxExport) Header(names []string) error {
+	for _, part := range xlsxParts {
+		f, err := self.zip.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return err
+		}
+	}
+	f, err := self.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	self.sheet = bufio.NewWriter(f)
+	_, err = io.WriteString(self.sheet, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`+
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	if err != nil {
+		return err
+	}
+	values := make([]interface{}, len(names))
+	for i, name := range names {
+		values[i] = name
+	}
+	return self.Row(values, nil)
+}
+
+func (self *xlsxExport) Row(values []interface{}, item interface{}) error {
+	self.sheet.WriteString("<row>")
+	for _, value := range values {
+		switch v := formatValue(value).(type) {
+		case nil:
+			self.sheet.WriteString("<c/>")
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			fmt.Fprintf(self.sheet, "<c><v>%v</v></c>", v)
+		case bool:
+			if v {
+				self.sheet.WriteString(`<c t="b"><v>1</v></c>`)
+			} else {
+				self.sheet.WriteString(`<c t="b"><v>0</v></c>`)
+			}
+		case string:
+			if err := self.text(escapeFormula(v)); err != nil {
+				return err
+			}
+		default:
+			if err := self.text(fmt.Sprint(v)); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := self.sheet.WriteString("</row>")
+	return err
+}
+
+// text writes an inline string cell
+func (self *xlsxExport) text(value string) error {
+	self.sheet.WriteString(`<c t="inlineStr"><is><t>`)
+	if err := xml.EscapeText(self.sheet, []byte(value)); err != nil {
+		return err
+	}
+	_, err := self.sheet.WriteString("</t></is></c>")
+	return err
+}
+
+func (self *xlsxExport) Flush() error {
+	if err := self.sheet.Flush(); err != nil {
+		return err
+	}
+	return self.zip.Flush()
+}
+
+func (self *xlsxExport) Close() error {
+	if _, err := self.sheet.WriteString("</sheetData></worksheet>"); err != nil {
+		return err
+	}
+	if err := self.sheet.Flush(); err != nil {
+		return err
+	}
+	return self.zip.Close()
+}
@@ -0,0 +1,139 @@
+package crudex
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// FilterPrefix prefixes the query parameters of the simple filters of the list, e.g. `f.Name=golf` or `f.Year.from=2000`
+//
+// They are the filters of the scaffolded list template, the API clients can use the OData `$filter` as well
+const FilterPrefix = "f."
+
+// Suffixes of the range filters, e.g. `f.Year.from=2000&f.Year.to=2010`
+const (
+	FilterFrom = ".from"
+	FilterTo   = ".to"
+)
+
+// FilterDateFormat is the format of the dates in the range filters, the values in RFC3339 are accepted as well
+const FilterDateFormat = "2006-01-02"
+
+// Filter applies the simple filters of the query parameters that start with FilterPrefix
+//
+//   - the text fields match the items that contain the value, e.g. `f.Name=golf`
+//   - the numeric and date fields match a range, e.g. `f.Year.from=2000&f.Year.to=2010` or `f.CreatedAt.from=2024-01-31`,
+//     a date without a time includes the whole day
+//   - the other fields match the value, e.g. `f.Active=true`
+//
+// The empty values are ignored, an unknown field or an invalid value is reported as an *HttpError with status 400
+func (self *CrudCtrl[T]) Filter(c *gin.Context, db *gorm.DB) (*gorm.DB, error) {
+	sch := self.Schema()
+	for param, values := range c.Request.URL.Query() {
+		if !strings.HasPrefix(param, FilterPrefix) || len(values) == 0 || values[0] == "" {
+			continue
+		}
+		name, op := strings.TrimPrefix(param, FilterPrefix), "="
+		if strings.HasSuffix(name, FilterFrom) {
+			name, op = strings.TrimSuffix(name, FilterFrom), ">="
+		} else if strings.HasSuffix(name, FilterTo) {
+			name, op = strings.TrimSuffix(name, FilterTo), "<="
+		}
+		field := sch.LookUpField(name)
+		if field == nil || field.DBName == "" {
+			return nil, NewHttpError(http.StatusBadRequest, fmt.Sprintf("Invalid filter: %s has no field %s", sch.Name, name))
+		}
+		column := clause.Column{Table: sch.Table, Name: field.DBName}
+		if op == "=" && isStringField(field) {
+			db = db.Where(likeExpr(column, values[0]))
+			continue
+		}
+		value, err := filterValue(field, values[0])
+		if err != nil {
+			return nil, NewHttpError(http.StatusBadRequest, fmt.Sprintf("Invalid filter %s: %s", param, err))
+		}
+		if date, ok := value.(time.Time); ok && op == "<=" && len(values[0]) == len(FilterDateFormat) {
+			// the whole day is included
+			db = db.Where(clause.Lt{Column: column, Value: date.AddDate(0, 0, 1)})
+			continue
+		}
+		switch op {
+		case ">=":
+			db = db.Where(clause.Gte{Column: column, Value: value})
+		case "<=":
+			db = db.Where(clause.Lte{Column: column, Value: value})
+		default:
+			db = db.Where(clause.Eq{Column: column, Value: value})
+		}
+	}
+	return db, nil
+}
+
+// filterValue converts the value of a filter to the type of the field, the pointer fields are filtered by their element
+func filterValue(field *schema.Field, str string) (interface{}, error) {
+	typ := field.FieldType
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return parseValue(typ, str)
+}
+
+// likeExpr returns the case insensitive `LIKE` condition that matches the values of the column that contain the term
+//
+// `!` is the escape character, the backslash is not used as it is an escape of the string literals in MySQL
+func likeExpr(column clause.Column, term string) clause.Expr {
+	return clause.Expr{SQL: "LOWER(?) LIKE ? ESCAPE '!'", Vars: []interface{}{column, likePattern(term)}}
+}
+
+// likePattern returns the case insensitive `LIKE` pattern that matches the values that contain the term
+func likePattern(term string) string {
+	escape := strings.NewReplacer(`!`, `!!`, `%`, `!%`, `_`, `!_`)
+	return fmt.Sprintf("%%%s%%", strings.ToLower(escape.Replace(term)))
+}
+
+// isStringField reports if the values of the field are strings, `LOWER` and `LIKE` can only be used on the strings
+func isStringField(field *schema.Field) bool {
+	typ := field.FieldType
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ.Kind() == reflect.String
+}
+
+// sortFuncs returns the `Sort` and `SortOrder` functions of the list templates
+//
+// `{{call $.Sort "Name"}}` returns the `$orderby` that toggles the order of the field,
+// `{{call $.SortOrder "Name"}}` returns the current order of the field, asc, desc or an empty string
+func (self *CrudCtrl[T]) sortFuncs(c *gin.Context) (func(name string) string, func(name string) string) {
+	sch := self.Schema()
+	current := strings.Fields(strings.Split(c.Query("$orderby"), ",")[0])
+	order := func(name string) string {
+		field := sch.LookUpField(name)
+		if field == nil || len(current) == 0 || !strings.EqualFold(current[0], field.DBName) && current[0] != field.Name {
+			return ""
+		}
+		if len(current) > 1 && strings.EqualFold(current[1], "desc") {
+			return "desc"
+		}
+		return "asc"
+	}
+	sort := func(name string) string {
+		column := name
+		if field := sch.LookUpField(name); field != nil && field.DBName != "" {
+			column = field.DBName
+		}
+		if order(name) == "asc" {
+			return fmt.Sprintf("%s desc", column)
+		}
+		return fmt.Sprintf("%s asc", column)
+	}
+	return sort, order
+}
@@ -1,10 +1,10 @@
 package crudex
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
 	"reflect"
-	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -34,56 +34,81 @@ func BindForm[T any](r *http.Request, out *T) error {
 }
 
 // DefaultFormHandler is a default form binder that binds the form data to a model using the form field names as the model field names
-func DefaultFormHandler[T IModel](c *gin.Context, out *T) error {
+//
+// The name of the form field can be changed with the `form` tag, fields tagged with `form:"-"` are skipped.
+// It handles both url encoded and multipart forms. The values that can not be converted to the type of their field
+// are reported with a *ValidationError, the rest of the fields are still bound
+//
+// Only the submitted fields are bound, so a PATCH changes only the fields of the form. A submitted empty value clears the field.
+// If a field is submitted more than once the last value wins, so a checkbox can follow a hidden input with `false`
+func DefaultFormHandler[T IEntity](c *gin.Context, out *T) error {
 	if err := c.Request.ParseForm(); err != nil {
 		return err
 	}
+	invalid := NewValidationError()
 	val := reflect.ValueOf(out).Elem()
 	typ := val.Type()
 	for i := 0; i < val.NumField(); i++ {
 		field := val.Field(i)
 		fieldType := typ.Field(i)
-		formValue := c.PostForm(fieldType.Name)
-
-		// we only set the field if we are able to do so and the form value is not empty
-		if field.CanSet() && formValue != "" {
-			switch fieldType.Type.Kind() {
-			case reflect.Uint:
-				val, err := strconv.ParseUint(formValue, 10, 64)
-				if err != nil {
-					return err
-				}
-				field.SetUint(val)
-			case reflect.String:
-				field.SetString(formValue)
-			case reflect.Int:
-				val, err := strconv.ParseInt(formValue, 10, 64)
-				if err != nil {
-					return err
-				}
-				field.SetInt(val)
-			case reflect.Float32:
-				val, err := strconv.ParseFloat(formValue, 32)
-				if err != nil {
-					return err
-				}
-				field.SetFloat(val)
-			case reflect.Float64:
-				val, err := strconv.ParseFloat(formValue, 64)
-				if err != nil {
-					return err
-				}
-				field.SetFloat(val)
-			case reflect.Bool:
-				if formValue == "true" || formValue == "checked" || formValue == "1" {
-					field.SetBool(true)
-				} else {
-					field.SetBool(false)
-				}
-			default:
-				return fmt.Errorf("Unsupported type: %s", fieldType.Type.Kind())
-			}
+		name := formFieldName(fieldType)
+		if name == "" || !field.CanSet() {
+			continue
+		}
+		values, ok := c.GetPostFormArray(name)
+		if !ok || len(values) == 0 {
+			continue
+		}
+		formValue := values[len(values)-1]
+		if formValue == "" {
+			field.Set(reflect.Zero(fieldType.Type))
+			continue
+		}
+		if err := setFormValue(field, fieldType, formValue, invalid); err != nil {
+			return err
 		}
 	}
+	if invalid.HasErrors() {
+		return invalid
+	}
 	return nil
 }
+
+// DefaultJSONHandler is a default json binder that binds the json body to a model respecting the `json` tags of the model
+//
+// The values that do not match the type of their field are reported with a *ValidationError
+func DefaultJSONHandler[T IEntity](c *gin.Context, out *T) error {
+	return jsonValidationError(c.ShouldBindJSON(out))
+}
+
+// setFormValue converts the submitted value to the type of the field with parseValue and sets it
+//
+// The values that can not be converted are added to the invalid errors, an error is returned only if the type of the field is not supported
+func setFormValue(field reflect.Value, fieldType reflect.StructField, formValue string, invalid *ValidationError) error {
+	value, err := parseValue(fieldType.Type, formValue)
+	if errors.Is(err, errUnsupportedType) {
+		return err
+	}
+	if err != nil {
+		invalid.Add(fieldType.Name, invalidValueMessage(fieldType.Name, fieldType.Type))
+		return nil
+	}
+	field.Set(reflect.ValueOf(value))
+	return nil
+}
+
+// formFieldName returns the name of the form field for the struct field
+//
+// It is the name in the `form` tag if present, otherwise the name of the field.
+// An empty string is returned if the field should be skipped
+func formFieldName(field reflect.StructField) string {
+	tag := strings.Split(field.Tag.Get("form"), ",")[0]
+	switch tag {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	default:
+		return tag
+	}
+}
@@ -0,0 +1,115 @@
+package crudex
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Hook is a function that is invoked on a lifecycle event of the CrudCtrl
+//
+// It receives the item that is being processed and it is free to modify it.
+// Returning an error stops the operation, if the error is an *HttpError its status is sent to the client.
+type Hook[T IEntity] func(c *gin.Context, item *T) error
+
+// ErrorHook is a function that is invoked whenever a CrudCtrl handler fails
+//
+// If the hook writes a response, the default error response of the controller is skipped
+type ErrorHook func(c *gin.Context, err error)
+
+// Hooks holds the lifecycle hooks of a CrudCtrl
+//
+// The hooks are executed in the order they were added, on the same way for HTML and JSON requests
+type Hooks[T IEntity] struct {
+	// BeforeBind is invoked before the request body is bound to the item
+	BeforeBind []Hook[T]
+	// AfterBind is invoked after the request body is bound to the item
+	AfterBind []Hook[T]
+	// BeforeSave is invoked right before the item is saved in the database
+	BeforeSave []Hook[T]
+	// AfterSave is invoked after the item is saved in the database
+	AfterSave []Hook[T]
+	// BeforeDelete is invoked right before the item is deleted from the database
+	BeforeDelete []Hook[T]
+	// AfterDelete is invoked after the item is deleted from the database
+	AfterDelete []Hook[T]
+	// OnError is invoked whenever a handler fails
+	OnError []ErrorHook
+}
+
+// run executes the hooks in order and stops on the first error
+func (self *Hooks[T]) run(c *gin.Context, hooks []Hook[T], item *T) error {
+	for _, hook := range hooks {
+		if err := hook(c, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OnBeforeBind adds a hook that is invoked before the request body is bound to the item in Upsert
+func (self *CrudCtrl[T]) OnBeforeBind(hook Hook[T]) *CrudCtrl[T] {
+	self.Hooks.BeforeBind = append(self.Hooks.BeforeBind, hook)
+	return self
+}
+
+// OnAfterBind adds a hook that is invoked after the request body is bound to the item in Upsert
+func (self *CrudCtrl[T]) OnAfterBind(hook Hook[T]) *CrudCtrl[T] {
+	self.Hooks.AfterBind = append(self.Hooks.AfterBind, hook)
+	return self
+}
+
+// OnBeforeSave adds a hook that is invoked before the item is saved in Upsert
+func (self *CrudCtrl[T]) OnBeforeSave(hook Hook[T]) *CrudCtrl[T] {
+	self.Hooks.BeforeSave = append(self.Hooks.BeforeSave, hook)
+	return self
+}
+
+// OnAfterSave adds a hook that is invoked after the item is saved in Upsert
+func (self *CrudCtrl[T]) OnAfterSave(hook Hook[T]) *CrudCtrl[T] {
+	self.Hooks.AfterSave = append(self.Hooks.AfterSave, hook)
+	return self
+}
+
+// OnBeforeDelete adds a hook that is invoked before the item is deleted in Delete
+func (self *CrudCtrl[T]) OnBeforeDelete(hook Hook[T]) *CrudCtrl[T] {
+	self.Hooks.BeforeDelete = append(self.Hooks.BeforeDelete, hook)
+	return self
+}
+
+// OnAfterDelete adds a hook that is invoked after the item is deleted in Delete
+func (self *CrudCtrl[T]) OnAfterDelete(hook Hook[T]) *CrudCtrl[T] {
+	self.Hooks.AfterDelete = append(self.Hooks.AfterDelete, hook)
+	return self
+}
+
+// OnError adds a hook that is invoked whenever a handler of the controller fails
+func (self *CrudCtrl[T]) OnError(hook ErrorHook) *CrudCtrl[T] {
+	self.Hooks.OnError = append(self.Hooks.OnError, hook)
+	return self
+}
+
+// fail reports the error to the error hooks and responds with the status of the error.
+//
+// The status is resolved with `ErrorStatus`, the provided status is used if the error does not carry one.
+// The response is negotiated with `RespondErrorWithConfig`
+func (self *CrudCtrl[T]) fail(c *gin.Context, status int, err error) {
+	if self.reportError(c, err) {
+		return
+	}
+	RespondErrorWithConfig(ErrorStatus(err, status), c, err, self.Config)
+	c.Abort()
+}
+
+// reportError adds the error to the context and invokes the error hooks
+//
+// It returns true if a hook already wrote the response
+func (self *CrudCtrl[T]) reportError(c *gin.Context, err error) bool {
+	_ = c.Error(err)
+	for _, hook := range self.Hooks.OnError {
+		hook(c, err)
+	}
+	if c.Writer.Written() {
+		c.Abort()
+		return true
+	}
+	return false
+}
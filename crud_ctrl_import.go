@@ -0,0 +1,246 @@
+package crudex
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/schema"
+)
+
+// ImportMaxBytes is the size limit of the body of the `/import` route, the larger files are rejected with 413
+var ImportMaxBytes int64 = 32 << 20
+
+// Import formats that are accepted by the `/import` route
+const (
+	ImportCSV  = "csv"
+	ImportJSON = "json"
+)
+
+// ImportForm is a handler that shows the form to upload the file of an import
+// it is a GET request
+// !Requires the template to be named as modelName-import.html where the modelName is lowercased model name
+func (self *CrudCtrl[T]) ImportForm(c *gin.Context) {
+	if err := self.authorizeImport(c); err != nil {
+		self.fail(c, http.StatusForbidden, err)
+		return
+	}
+	self.Respond(c, gin.H{"Path": self.BasePath()}, self.importTemplate())
+}
+
+// Import is a handler that creates or updates the items of a CSV or JSON file
+// it is a POST request
+//
+// The file is read from the `file` field of a multipart form, or from the request body. The format is detected
+// from the extension of the file or the content type:
+//   - CSV files have a header row with the names of the fields, the values are converted like the form values(see DefaultFormHandler)
+//   - JSON files hold an array of items, or an item per line(NDJSON)
+//
+// The body is limited to ImportMaxBytes and the rows are read one by one as they are saved.
+// The items are saved like the items of `POST /bulk`, the ones with an existing key are updated and the rest are created.
+// See `runBulk` for the modes, the dry run(`?dry_run=true`) and the report of every row
+func (self *CrudCtrl[T]) Import(c *gin.Context) {
+	if err := self.authorizeImport(c); err != nil {
+		self.fail(c, http.StatusForbidden, err)
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, ImportMaxBytes)
+	file, format, err := self.importFile(c)
+	if err != nil {
+		self.fail(c, http.StatusBadRequest, err)
+		return
+	}
+	defer file.Close()
+	var next bulkNext
+	var decode func(index int, item *T) error
+	switch format {
+	case ImportCSV:
+		next, decode, err = self.csvDecoder(file)
+	default:
+		next, decode, err = jsonDecoder[T](file)
+	}
+	if err != nil {
+		self.fail(c, http.StatusBadRequest, err)
+		return
+	}
+	self.saveAll(c, false, next, self.importTemplate(), decode)
+}
+
+func (self *CrudCtrl[T]) importTemplate() string {
+	return fmt.Sprintf("%s-import.html", strings.ToLower(self.ModelName))
+}
+
+// authorizeImport checks that the request can either create or update the items, every row is authorized again when it is saved
+func (self *CrudCtrl[T]) authorizeImport(c *gin.Context) error {
+	err := self.authorize(c, ActionCreate, nil)
+	if err != nil && self.authorize(c, ActionUpdate, nil) == nil {
+		return nil
+	}
+	return err
+}
+
+// importFile opens the uploaded file and detects its format
+func (self *CrudCtrl[T]) importFile(c *gin.Context) (io.ReadCloser, string, error) {
+	contentType, name := c.ContentType(), ""
+	var file io.ReadCloser = c.Request.Body
+	if contentType == gin.MIMEMultipartPOSTForm {
+		header, err := c.FormFile("file")
+		if err != nil {
+			if importErr := importError(err); ErrorStatus(importErr, 0) == http.StatusRequestEntityTooLarge {
+				return nil, "", importErr
+			}
+			return nil, "", NewHttpError(http.StatusBadRequest, "The file to import is missing")
+		}
+		if file, err = header.Open(); err != nil {
+			return nil, "", err
+		}
+		name = header.Filename
+		contentType, _, _ = mime.ParseMediaType(header.Header.Get("Content-Type"))
+	}
+	switch {
+	case strings.EqualFold(filepath.Ext(name), ".csv") || contentType == "text/csv":
+		return file, ImportCSV, nil
+	case strings.EqualFold(filepath.Ext(name), ".json") || strings.EqualFold(filepath.Ext(name), ".ndjson") ||
+		contentType == gin.MIMEJSON || contentType == "application/x-ndjson":
+		return file, ImportJSON, nil
+	default:
+		file.Close()
+		return nil, "", NewHttpError(http.StatusUnsupportedMediaType, "Only CSV and JSON files can be imported")
+	}
+}
+
+// importError converts an error of reading the file, the files that are larger than ImportMaxBytes fail with 413
+func importError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return NewHttpError(http.StatusRequestEntityTooLarge, fmt.Sprintf("The file is larger than %d bytes", tooLarge.Limit))
+	}
+	return WrapHttpError(http.StatusBadRequest, err)
+}
+
+// csvDecoder reads the rows of the csv file one by one, the columns are matched to the fields by their form name or column name
+//
+// The columns of the fields that are managed by gorm(timestamps and soft deletes) are ignored and the quote
+// that the export adds before the formulas is removed, so an exported file can be imported back. Any other unknown column fails the import
+func (self *CrudCtrl[T]) csvDecoder(file io.Reader) (bulkNext, func(index int, item *T) error, error) {
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, NewHttpError(http.StatusBadRequest, "The CSV file has no header")
+	}
+	if err != nil {
+		return nil, nil, importError(err)
+	}
+	sch := self.Schema()
+	softDelete := softDeleteField(sch)
+	columns := map[*schema.Field]int{}
+	for i, name := range header {
+		field := importField(sch, strings.TrimSpace(name))
+		if field == nil {
+			return nil, nil, NewHttpError(http.StatusBadRequest, fmt.Sprintf("Unknown column %s, %s has no such field", name, sch.Name))
+		}
+		if field.AutoCreateTime > 0 || field.AutoUpdateTime > 0 || field == softDelete {
+			continue
+		}
+		columns[field] = i
+	}
+	var record []string
+	next := func(index int) (bool, error) {
+		if record, err = reader.Read(); errors.Is(err, io.EOF) {
+			return false, nil
+		} else if err != nil {
+			return false, importError(err)
+		}
+		return true, nil
+	}
+	decode := func(index int, item *T) error {
+		invalid := NewValidationError()
+		val := reflect.ValueOf(item).Elem()
+		for field, column := range columns {
+			if column >= len(record) || record[column] == "" {
+				continue
+			}
+			if err := setFormValue(field.ReflectValueOf(context.Background(), val), field.StructField, unescapeFormula(record[column]), invalid); err != nil {
+				return err
+			}
+		}
+		if invalid.HasErrors() {
+			return invalid
+		}
+		return nil
+	}
+	return next, decode, nil
+}
+
+// importField returns the field of the csv column, by its form name, field name or column name
+func importField(sch *schema.Schema, name string) *schema.Field {
+	for _, field := range sch.Fields {
+		if field.DBName != "" && formFieldName(field.StructField) == name {
+			return field
+		}
+	}
+	if field := sch.LookUpField(name); field != nil && field.DBName != "" {
+		return field
+	}
+	return nil
+}
+
+// jsonDecoder reads the items of a json array or of a json document per line one by one
+func jsonDecoder[T IEntity](file io.Reader) (bulkNext, func(index int, item *T) error, error) {
+	reader := bufio.NewReader(file)
+	first, err := firstByte(reader)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, nil, importError(err)
+	}
+	decoder := json.NewDecoder(reader)
+	array := first == '['
+	if array {
+		if _, err := decoder.Token(); err != nil {
+			return nil, nil, importError(err)
+		}
+	}
+	var raw json.RawMessage
+	next := func(index int) (bool, error) {
+		if array && !decoder.More() {
+			if _, err := decoder.Token(); err != nil {
+				return false, importError(err)
+			}
+			return false, nil
+		}
+		raw = nil
+		if err := decoder.Decode(&raw); errors.Is(err, io.EOF) && !array {
+			return false, nil
+		} else if err != nil {
+			return false, importError(err)
+		}
+		return true, nil
+	}
+	return next, func(index int, item *T) error {
+		return jsonValidationError(json.Unmarshal(raw, item))
+	}, nil
+}
+
+// firstByte returns the first byte of the reader that is not a white space, the byte is not consumed
+func firstByte(reader *bufio.Reader) (byte, error) {
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if !unicode.IsSpace(rune(b)) {
+			return b, reader.UnreadByte()
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package crudex
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Page is the paging of a list, computed from the `$top` and `$skip` query parameters
+type Page struct {
+	// Size is the number of items per page, it is 0 if the list is not paged
+	Size int
+	// Skip is the number of items before the page
+	Skip int
+	// Count is the total number of items, it is set with `$count=true` and always in the html lists
+	Count *int64 `json:",omitempty"`
+	// HasPrev is true if there are items before the page
+	HasPrev bool
+	// HasNext is true if there are items after the page
+	HasNext bool
+	// PrevLink is the url of the previous page
+	PrevLink string `json:",omitempty"`
+	// NextLink is the url of the next page
+	NextLink string `json:",omitempty"`
+	// Keyset is true if the list is paged with cursors instead of `$skip`
+	Keyset bool `json:",omitempty"`
+	// Cursor is the `$cursor` of the page, it is empty on the first page
+	Cursor string `json:",omitempty"`
+	// NextCursor is the `$cursor` of the next page
+	NextCursor string `json:",omitempty"`
+	// keys are the sort keys of the keyset paging
+	keys []sortKey
+	// empty is true if the page is requested with `$top=0`, it has no items but it can have the count
+	empty bool
+}
+
+// PageLinks are the links of the previous and next pages of a json list
+type PageLinks struct {
+	Prev string `json:"prev,omitempty"`
+	Next string `json:"next,omitempty"`
+}
+
+// listData returns the data of a list response
+//
+// The html templates get the items as `<name>List` with the Path and the Page, the json responses get a fixed envelope
+// with the `items`, the total `count`, the `links` of the pages and the `page`, so the api clients do not depend on the name of the model.
+// The count of a list that is not paged is the number of its items, the count of a page is set with `$count=true`
+func listData(c *gin.Context, conf IConfig, name string, items interface{}, path string, page *Page) gin.H {
+	if negotiate(c, conf) == responseUI {
+		data := gin.H{fmt.Sprintf("%sList", name): items, "Path": path}
+		if page != nil {
+			data["Page"] = page
+		}
+		return data
+	}
+	data := gin.H{"items": items, "links": PageLinks{}}
+	if page == nil {
+		data["count"] = reflect.Indirect(reflect.ValueOf(items)).Len()
+		return data
+	}
+	data["page"], data["links"] = page, PageLinks{Prev: page.PrevLink, Next: page.NextLink}
+	if page.Count != nil {
+		data["count"] = *page.Count
+	}
+	return data
+}
+
+// Prev returns the `$skip` of the previous page
+func (self *Page) Prev() int {
+	return max(self.Skip-self.Size, 0)
+}
+
+// Next returns the `$skip` of the next page
+func (self *Page) Next() int {
+	return self.Skip + self.Size
+}
+
+// link returns the url of the request with the given `$skip` and the size of the page
+func (self *Page) link(u *url.URL, skip int) string {
+	query := u.Query()
+	query.Set("$skip", strconv.Itoa(skip))
+	query.Set("$top", strconv.Itoa(self.Size))
+	return (&url.URL{Path: u.Path, RawQuery: query.Encode()}).String()
+}
+
+// cursorLink returns the url of the request with the given `$cursor` and the size of the page
+func (self *Page) cursorLink(u *url.URL, cursor string) string {
+	query := u.Query()
+	query.Del("$skip")
+	query.Set(CursorParam, cursor)
+	query.Set("$top", strconv.Itoa(self.Size))
+	return (&url.URL{Path: u.Path, RawQuery: query.Encode()}).String()
+}
+
+// Paging returns the Page of the request
+//
+// The size of the page is the `$top` query parameter or the default page size of the configuration,
+// it is reduced to the maximum page size of the configuration. An explicit `$top=0` returns an empty page,
+// e.g. `$top=0&$count=true` returns only the count
+func (self *CrudCtrl[T]) Paging(c *gin.Context) *Page {
+	page := &Page{}
+	top, hasTop := c.GetQuery("$top")
+	size, err := strconv.Atoi(top)
+	page.Size, page.empty = size, hasTop && err == nil && size == 0
+	page.Skip, _ = strconv.Atoi(c.Query("$skip"))
+	page.Size, page.Skip = max(page.Size, 0), max(page.Skip, 0)
+	if page.empty {
+		return page
+	}
+	if page.Size == 0 {
+		page.Size = self.options().DefaultPageSize()
+	}
+	if limit := self.options().MaxPageSize(); limit > 0 && (page.Size == 0 || page.Size > limit) {
+		page.Size = limit
+	}
+	page.HasPrev = page.Skip > 0
+	return page
+}
+
+// countItems sets the total count of the page, if it is requested with `$count=true` or if always is true
+//
+// It should be called before the relations are preloaded
+func (self *CrudCtrl[T]) countItems(c *gin.Context, db *gorm.DB, page *Page, always bool) error {
+	return countPage(c, db.Model(new(T)), page, always)
+}
+
+// countPage sets the total count of the page from the query of its model, see `countItems`
+func countPage(c *gin.Context, db *gorm.DB, page *Page, always bool) error {
+	if requested, _ := strconv.ParseBool(c.Query("$count")); !requested && !always {
+		return nil
+	}
+	var total int64
+	if err := db.Session(&gorm.Session{}).Limit(-1).Offset(-1).Count(&total).Error; err != nil {
+		return err
+	}
+	page.Count = &total
+	return nil
+}
+
+// paginate loads the items of the page from the query and fills the links of the page
+func (self *CrudCtrl[T]) paginate(c *gin.Context, db *gorm.DB, page *Page, items *[]T) error {
+	if err := loadPage(c, db, page, items); err != nil {
+		return err
+	}
+	if page.Keyset && page.HasNext {
+		next, err := encodeCursor(&(*items)[len(*items)-1], page.keys)
+		if err != nil {
+			return err
+		}
+		page.NextCursor, page.NextLink = next, page.cursorLink(c.Request.URL, next)
+	}
+	return nil
+}
+
+// loadPage loads the items of the page from the query into the slice that items points to, and fills the `$skip` links of the page.
+// The links of the keyset pages are filled by `paginate`
+func loadPage(c *gin.Context, db *gorm.DB, page *Page, items interface{}) error {
+	if page.empty {
+		list := reflect.ValueOf(items).Elem()
+		list.Set(reflect.MakeSlice(list.Type(), 0, 0))
+		return nil
+	}
+	if page.Size > 0 {
+		// one more item is loaded to know if there is a next page
+		db = db.Limit(page.Size + 1)
+		if !page.Keyset {
+			db = db.Offset(page.Skip)
+		}
+	}
+	if err := db.Find(items).Error; err != nil {
+		return err
+	}
+	if list := reflect.ValueOf(items).Elem(); page.Size > 0 && list.Len() > page.Size {
+		page.HasNext = true
+		list.Set(list.Slice(0, page.Size))
+	}
+	if page.Keyset {
+		return nil
+	}
+	if page.HasPrev {
+		page.PrevLink = page.link(c.Request.URL, page.Prev())
+	}
+	if page.HasNext {
+		page.NextLink = page.link(c.Request.URL, page.Next())
+	}
+	return nil
+}
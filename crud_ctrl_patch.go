@@ -0,0 +1,170 @@
+package crudex
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// MIMEMergePatch is the content type of a JSON Merge Patch(RFC 7396) document
+	MIMEMergePatch = "application/merge-patch+json"
+	// MIMEJSONPatch is the content type of a JSON Patch(RFC 6902) document
+	MIMEJSONPatch = "application/json-patch+json"
+)
+
+// Patch is a handler that partially updates an item of the model
+// it is a PATCH request
+//
+// The existing item is loaded and the request body is applied on top of it:
+//   - application/merge-patch+json and application/json are applied as a JSON Merge Patch(RFC 7396)
+//   - application/json-patch+json is applied as a JSON Patch(RFC 6902)
+//   - form data is bound on top of the existing item, so only the submitted fields are changed
+//
+// Only the columns that were changed are saved. It redirects to the details page of the saved item
+func (self *CrudCtrl[T]) Patch(c *gin.Context) {
+	key, err := self.parseKey(c)
+	if err != nil {
+		self.fail(c, http.StatusBadRequest, err)
+		return
+	}
+	var existing T
+	if err := self.find(c, key, &existing); err != nil {
+		self.fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	if err := self.authorize(c, ActionUpdate, &existing); err != nil {
+		self.fail(c, http.StatusForbidden, err)
+		return
+	}
+	if err := self.CheckPrecondition(c, &existing); err != nil {
+		self.fail(c, http.StatusPreconditionFailed, err)
+		return
+	}
+	item := existing
+	if err := self.Hooks.run(c, self.Hooks.BeforeBind, &item); err != nil {
+		self.fail(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := self.ApplyPatch(c, &item); err != nil {
+		self.failValidation(c, http.StatusBadRequest, err, &item, &existing)
+		return
+	}
+	if err := self.Hooks.run(c, self.Hooks.AfterBind, &item); err != nil {
+		self.fail(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := self.stampTenant(c, &item); err != nil {
+		self.fail(c, http.StatusForbidden, err)
+		return
+	}
+	// the patched item is authorized as well, so it can not be moved outside of what the user can change
+	if err := self.authorize(c, ActionUpdate, &item); err != nil {
+		self.fail(c, http.StatusForbidden, err)
+		return
+	}
+	if err := self.Validate(c, &item); err != nil {
+		self.failValidation(c, http.StatusUnprocessableEntity, err, &item, &existing)
+		return
+	}
+	if err := self.Hooks.run(c, self.Hooks.BeforeSave, &item); err != nil {
+		self.fail(c, http.StatusBadRequest, err)
+		return
+	}
+	columns := []interface{}{}
+	for _, field := range changedFields(self.Schema(), &existing, &item) {
+		if field.PrimaryKey {
+			self.fail(c, http.StatusBadRequest, NewHttpError(http.StatusBadRequest, fmt.Sprintf("%s can not be changed", field.Name)))
+			return
+		}
+		columns = append(columns, field.Name)
+	}
+	change, err := self.commit(c, func(tx *gorm.DB) (*Change[T], error) {
+		if len(columns) == 0 {
+			return nil, nil
+		}
+		result := self.whereVersion(c, tx, &existing).Model(&item).Select(columns[0], columns[1:]...).Updates(&item)
+		if err := self.checkWritten(c, result, &item); err != nil {
+			return nil, err
+		}
+		self.refreshVersion(tx, &item)
+		return &Change[T]{Action: ActionUpdate, Key: self.Keys.Format(&item), Before: &existing, After: &item}, nil
+	})
+	if err != nil {
+		self.fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	if err := self.Hooks.run(c, self.Hooks.AfterSave, &item); err != nil {
+		self.fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	if change != nil {
+		self.notify(c, *change)
+	}
+	if etag := self.ETag(&item); etag != "" {
+		c.Header("ETag", etag)
+	}
+	c.Header("HX-Redirect", fmt.Sprintf("%s/%s", self.BasePath(), self.Keys.Format(&item)))
+	c.String(http.StatusOK, "Saved")
+	c.Abort()
+}
+
+// ApplyPatch applies the request body on top of the item based on the request content type
+//
+// See `Patch` for the supported content types
+func (self *CrudCtrl[T]) ApplyPatch(c *gin.Context, item *T) error {
+	contentType := c.ContentType()
+	if contentType != gin.MIMEJSON && contentType != MIMEMergePatch && contentType != MIMEJSONPatch {
+		return self.Bind(c, item)
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return err
+	}
+	doc, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	if contentType == MIMEJSONPatch {
+		patch, err := jsonpatch.DecodePatch(body)
+		if err != nil {
+			return WrapHttpError(http.StatusBadRequest, err)
+		}
+		doc, err = patch.Apply(doc)
+		if err != nil {
+			return WrapHttpError(http.StatusUnprocessableEntity, err)
+		}
+	} else {
+		doc, err = jsonpatch.MergePatch(doc, body)
+		if err != nil {
+			return WrapHttpError(http.StatusBadRequest, err)
+		}
+	}
+	// the patched document is decoded in a fresh item so the removed members end up with zero values
+	var patched T
+	if err := json.Unmarshal(doc, &patched); err != nil {
+		if invalid := jsonValidationError(err); invalid != err {
+			return invalid
+		}
+		return WrapHttpError(http.StatusUnprocessableEntity, err)
+	}
+	// the fields that are hidden from json can not be patched, so they keep their current values
+	itemVal := reflect.ValueOf(item).Elem()
+	patchedVal := reflect.ValueOf(&patched).Elem()
+	for _, field := range self.Schema().Fields {
+		if field.StructField.Tag.Get("json") == "-" {
+			value, _ := field.ValueOf(c, itemVal)
+			if err := field.Set(c, patchedVal, value); err != nil {
+				return err
+			}
+		}
+	}
+	*item = patched
+	return nil
+}
@@ -0,0 +1,148 @@
+package crudex
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	odata "github.com/pboyd04/godata"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ExpandSeparator separates the nested relations in the `$expand` query parameter, e.g. `$expand=Drivers.Car`
+const ExpandSeparator = "."
+
+// Relations returns the has-one, belongs-to and has-many relations of the model
+//
+// For every relation a nested route `/:id/<relation>` is registered, e.g. `/cars/:id/drivers`
+func (self *CrudCtrl[T]) Relations() []*schema.Relationship {
+	return relations(self.Schema())
+}
+
+// RelationRoutes returns the last segment of the routes of the related items, e.g. `players` for `/:id/players`
+func (self *CrudCtrl[T]) RelationRoutes() []string {
+	routes := []string{}
+	for _, rel := range self.Relations() {
+		routes = append(routes, strings.ToLower(rel.Name))
+	}
+	return routes
+}
+
+func relations(sch *schema.Schema) []*schema.Relationship {
+	rels := []*schema.Relationship{}
+	rels = append(rels, sch.Relationships.HasOne...)
+	rels = append(rels, sch.Relationships.BelongsTo...)
+	rels = append(rels, sch.Relationships.HasMany...)
+	return rels
+}
+
+// findRelation looks up the relation by its name, ignoring the case
+func findRelation(sch *schema.Schema, name string) *schema.Relationship {
+	if rel, ok := sch.Relationships.Relations[name]; ok {
+		return rel
+	}
+	for relName, rel := range sch.Relationships.Relations {
+		if strings.EqualFold(relName, name) {
+			return rel
+		}
+	}
+	return nil
+}
+
+// Expand preloads the relations listed in the `$expand` query parameter
+//
+// The relations are separated with a comma and nested relations with `ExpandSeparator`, e.g. `$expand=Car,Team.Owner`.
+// The names are matched case insensitive. An unknown relation is reported as an *HttpError with status 400
+func (self *CrudCtrl[T]) Expand(c *gin.Context, db *gorm.DB) (*gorm.DB, error) {
+	expand := c.Query("$expand")
+	if expand == "" {
+		return db, nil
+	}
+	for _, path := range strings.Split(expand, ",") {
+		sch := self.Schema()
+		names := []string{}
+		for _, name := range strings.Split(strings.TrimSpace(path), ExpandSeparator) {
+			rel := findRelation(sch, name)
+			if rel == nil {
+				return nil, NewHttpError(http.StatusBadRequest, fmt.Sprintf("Invalid $expand: %s has no relation %s", sch.Name, name))
+			}
+			names = append(names, rel.Name)
+			sch = rel.FieldSchema
+		}
+		db = db.Preload(strings.Join(names, ExpandSeparator))
+	}
+	return db, nil
+}
+
+// Related returns a handler that lists the items of the relation that belong to the item with the given id
+// it is a GET request
+//
+// Has-many relations respond with a page of the related items, with the `$filter`, `$orderby` and paging of the lists.
+// Has-one and belongs-to relations respond with the single related item.
+// The templates of the related items get no actions, the actions are authorized by the controller of the related model.
+// !Requires the templates of the related model (relatedModelName-related.html or relatedModelName.html) for html responses,
+// the related template is generated by ScaffoldDefaults for every has-many relation
+func (self *CrudCtrl[T]) Related(rel *schema.Relationship) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, err := self.parseKey(c)
+		if err != nil {
+			self.fail(c, http.StatusBadRequest, err)
+			return
+		}
+		var parent T
+		if err := self.find(c, key, &parent); err != nil {
+			self.fail(c, http.StatusInternalServerError, err)
+			return
+		}
+		if err := self.authorize(c, ActionDetails, &parent); err != nil {
+			self.fail(c, http.StatusForbidden, err)
+			return
+		}
+		path := fmt.Sprintf("%s/%s/%s", self.BasePath(), rawParam(c, "id"), strings.ToLower(rel.Name))
+		relatedName := rel.FieldSchema.Name
+		related := reflect.New(reflect.SliceOf(rel.FieldSchema.ModelType))
+		if rel.Type == schema.HasMany {
+			page, err := self.relatedPage(c, rel, &parent, related.Interface())
+			if err != nil {
+				self.fail(c, http.StatusInternalServerError, err)
+				return
+			}
+			RespondWithConfig(http.StatusOK, c,
+				listData(c, self.Config, relatedName, related.Interface(), path, page),
+				fmt.Sprintf("%s-related.html", strings.ToLower(relatedName)), self.Config)
+			return
+		}
+		if err := self.Db.Model(&parent).Association(rel.Name).Find(related.Interface()); err != nil {
+			self.fail(c, http.StatusInternalServerError, err)
+			return
+		}
+		if related.Elem().Len() == 0 {
+			self.fail(c, http.StatusNotFound, NewHttpError(http.StatusNotFound, fmt.Sprintf("%s %s has no %s", self.ModelName, c.Param("id"), rel.Name)))
+			return
+		}
+		RespondWithConfig(http.StatusOK, c,
+			gin.H{relatedName: related.Elem().Index(0).Interface(), "Path": path},
+			fmt.Sprintf("%s.html", strings.ToLower(relatedName)), self.Config)
+	}
+}
+
+// relatedPage loads the page of the has-many relation of the parent into the slice that items points to
+//
+// The related items are filtered and sorted with `$filter` and `$orderby`, and paged like the lists(see `Paging`)
+func (self *CrudCtrl[T]) relatedPage(c *gin.Context, rel *schema.Relationship, parent *T, items interface{}) (*Page, error) {
+	conds := rel.ToQueryConditions(c, reflect.ValueOf(parent).Elem())
+	db := self.Db.Model(reflect.New(rel.FieldSchema.ModelType).Interface()).Where(clause.And(conds...))
+	db, err := odata.GetGormSettingsFromGin(c, db)
+	if err != nil {
+		return nil, NewHttpError(http.StatusBadRequest, err.Error())
+	}
+	page := self.Paging(c)
+	if err := countPage(c, db, page, negotiate(c, self.Config) == responseUI); err != nil {
+		return nil, err
+	}
+	return page, loadPage(c, db, page, items)
+}
@@ -0,0 +1,132 @@
+package crudex
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Projection holds the fields of the model that were requested with the `$select` query parameter
+//
+// The primary key fields are always part of the projection so the projected items can still be linked.
+// The relations listed in `$expand` are part of the projection as well
+type Projection struct {
+	// Fields are the projected fields in the order of the model
+	Fields []*schema.Field
+	// Columns are the columns that are loaded from the database
+	Columns []string
+	typ     reflect.Type
+}
+
+// Selection parses the `$select` query parameter into a Projection
+//
+// It returns nil if there is no `$select`. The fields can be referenced by their name or their column name,
+// an unknown field is reported as an *HttpError with status 400
+func (self *CrudCtrl[T]) Selection(c *gin.Context) (*Projection, error) {
+	selection := c.Query("$select")
+	if selection == "" {
+		return nil, nil
+	}
+	sch := self.Schema()
+	selected := map[*schema.Field]bool{}
+	for _, field := range sch.PrimaryFields {
+		selected[field] = true
+	}
+	for _, name := range strings.Split(selection, ",") {
+		field := sch.LookUpField(strings.TrimSpace(name))
+		if field == nil || field.DBName == "" {
+			return nil, NewHttpError(http.StatusBadRequest, fmt.Sprintf("Invalid $select: %s has no field %s", sch.Name, name))
+		}
+		selected[field] = true
+	}
+	// the columns that join the expanded relations are loaded as well, otherwise the preload does not match anything
+	joins := map[*schema.Field]bool{}
+	for _, path := range strings.Split(c.Query("$expand"), ",") {
+		name := strings.Split(strings.TrimSpace(path), ExpandSeparator)[0]
+		if rel := findRelation(sch, name); rel != nil {
+			selected[rel.Field] = true
+			for _, ref := range rel.References {
+				if ref.ForeignKey != nil && ref.ForeignKey.Schema == sch {
+					joins[ref.ForeignKey] = true
+				}
+				if ref.PrimaryKey != nil && ref.PrimaryKey.Schema == sch {
+					joins[ref.PrimaryKey] = true
+				}
+			}
+		}
+	}
+
+	projection := &Projection{}
+	structFields := []reflect.StructField{}
+	for _, field := range sch.Fields {
+		// the version field is always loaded so the ETag of the item can be computed
+		if field.DBName != "" && (selected[field] || joins[field] || field.AutoUpdateTime > 0) {
+			projection.Columns = append(projection.Columns, field.DBName)
+		}
+		if selected[field] {
+			projection.Fields = append(projection.Fields, field)
+			structFields = append(structFields, reflect.StructField{
+				Name: field.Name,
+				Type: field.FieldType,
+				Tag:  field.Tag,
+			})
+		}
+	}
+	projection.typ = reflect.StructOf(structFields)
+	return projection, nil
+}
+
+// Apply restricts the query to the projected columns
+func (self *Projection) Apply(db *gorm.DB) *gorm.DB {
+	if self == nil {
+		return db
+	}
+	return db.Select(self.Columns)
+}
+
+// Project copies the projected fields of the item into a new struct that holds only those fields
+//
+// The struct keeps the tags of the model fields, so it is serialized the same way as the model
+func (self *Projection) Project(item interface{}) interface{} {
+	src := reflect.Indirect(reflect.ValueOf(item))
+	dst := reflect.New(self.typ).Elem()
+	for i, field := range self.Fields {
+		value, _ := field.ValueOf(context.Background(), src)
+		if value != nil {
+			dst.Field(i).Set(reflect.ValueOf(value))
+		}
+	}
+	return dst.Interface()
+}
+
+// Values returns the projected fields of the item mapped by their field name
+//
+// It is used for the html templates, so the fields that are not selected render as empty instead of failing the template
+func (self *Projection) Values(item interface{}) map[string]interface{} {
+	src := reflect.Indirect(reflect.ValueOf(item))
+	values := make(map[string]interface{}, len(self.Fields))
+	for _, field := range self.Fields {
+		values[field.Name], _ = field.ValueOf(context.Background(), src)
+	}
+	return values
+}
+
+// ProjectAll projects every item of the slice, with `Values` if asMap is true or with `Project` otherwise
+func (self *Projection) ProjectAll(items interface{}, asMap bool) []interface{} {
+	src := reflect.Indirect(reflect.ValueOf(items))
+	projected := make([]interface{}, src.Len())
+	for i := range projected {
+		if asMap {
+			projected[i] = self.Values(src.Index(i).Addr().Interface())
+		} else {
+			projected[i] = self.Project(src.Index(i).Addr().Interface())
+		}
+	}
+	return projected
+}
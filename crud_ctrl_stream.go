@@ -0,0 +1,172 @@
+package crudex
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Names of the events of the change stream
+const (
+	EventCreated = "created"
+	EventUpdated = "updated"
+	EventDeleted = "deleted"
+)
+
+// StreamKeepAlive is the interval of the comments that keep the idle change streams open through the proxies
+var StreamKeepAlive = 30 * time.Second
+
+// StreamBuffer is the number of events that are buffered for a slow subscriber, the newer events are dropped when it is full
+var StreamBuffer = 16
+
+// ChangeEvent is sent to the subscribers of the change stream after an item is saved or deleted
+type ChangeEvent struct {
+	// Event is the name of the event, created, updated or deleted
+	Event string `json:"event"`
+	// Model is the name of the model
+	Model string `json:"model"`
+	// Key is the route key of the changed item
+	Key string `json:"key"`
+	// Item is the item after the change, or before the change for the deleted items
+	Item interface{} `json:"item"`
+}
+
+// changeStream dispatches the changes of a controller to the subscribers of its event stream
+type changeStream[T IEntity] struct {
+	mu          sync.Mutex
+	subscribers map[chan Change[T]]bool
+}
+
+func (self *changeStream[T]) subscribe() chan Change[T] {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	ch := make(chan Change[T], StreamBuffer)
+	self.subscribers[ch] = true
+	return ch
+}
+
+func (self *changeStream[T]) unsubscribe(ch chan Change[T]) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	delete(self.subscribers, ch)
+}
+
+// publish sends the change to every subscriber without waiting, so a slow client can not block the writes
+func (self *changeStream[T]) publish(change Change[T]) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	for ch := range self.subscribers {
+		select {
+		case ch <- change:
+		default:
+		}
+	}
+}
+
+// WithChangeStream exposes the changes of the items as Server-Sent Events on the `/events` route
+//
+// The created, updated and deleted events are sent after the changes are saved, see ChangeEvent.
+// The scaffolded list subscribes to them with the htmx sse extension and reloads its rows
+func (self *CrudCtrl[T]) WithChangeStream() *CrudCtrl[T] {
+	if self.stream != nil {
+		return self
+	}
+	self.stream = &changeStream[T]{subscribers: map[chan Change[T]]bool{}}
+	if self.Router != nil {
+		self.Router.GET("/events", self.requireAction(self.Events, ActionList))
+	}
+	return self
+}
+
+// HasChangeStream returns true if the changes are streamed on the `/events` route, see WithChangeStream
+func (self *CrudCtrl[T]) HasChangeStream() bool {
+	return self.stream != nil
+}
+
+// Events is a handler that streams the changes of the items as Server-Sent Events
+// it is a GET request, the stream is open until the client disconnects
+//
+// The events can be limited to some items with their keys, e.g. `?id=1&id=2`.
+// Only the items that the request can see are sent, the items of other tenants and the items that the Authorizer does not allow
+func (self *CrudCtrl[T]) Events(c *gin.Context) {
+	if err := self.authorize(c, ActionList, nil); err != nil {
+		self.fail(c, http.StatusForbidden, err)
+		return
+	}
+	if self.stream == nil {
+		self.fail(c, http.StatusNotFound, NewHttpError(http.StatusNotFound, "The change stream is not enabled"))
+		return
+	}
+	tenant, err := self.Tenant(c)
+	if err != nil {
+		self.fail(c, http.StatusForbidden, err)
+		return
+	}
+	keys := c.QueryArray("id")
+	ch := self.stream.subscribe()
+	defer self.stream.unsubscribe(ch)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+	keepAlive := time.NewTicker(StreamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := io.WriteString(c.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case change := <-ch:
+			if len(keys) > 0 && !slices.Contains(keys, change.Key) {
+				continue
+			}
+			event := change.Event(self.ModelName)
+			if !self.visible(c, tenant, event.Item) {
+				continue
+			}
+			c.SSEvent(event.Event, event)
+			c.Writer.Flush()
+		}
+	}
+}
+
+// visible returns true if the item belongs to the tenant and the details of the item are allowed for the request
+func (self *CrudCtrl[T]) visible(c *gin.Context, tenant interface{}, item interface{}) bool {
+	target, ok := item.(*T)
+	if !ok || target == nil {
+		return false
+	}
+	if field := self.TenantField(); field != nil {
+		value, _ := field.ValueOf(context.Background(), reflect.ValueOf(target).Elem())
+		if !reflect.DeepEqual(value, tenant) {
+			return false
+		}
+	}
+	return self.authorize(c, ActionDetails, target) == nil
+}
+
+// Event returns the ChangeEvent of the change, the restored items are created and the purged items are deleted
+func (self Change[T]) Event(model string) ChangeEvent {
+	event := ChangeEvent{Model: model, Key: self.Key, Item: self.After}
+	switch self.Action {
+	case ActionCreate, ActionRestore:
+		event.Event = EventCreated
+	case ActionDelete, ActionPurge:
+		event.Event, event.Item = EventDeleted, self.Before
+	default:
+		event.Event = EventUpdated
+	}
+	return event
+}
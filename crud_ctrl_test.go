@@ -0,0 +1,2077 @@
+package crudex
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	ginrender "github.com/gin-gonic/gin/render"
+	odata "github.com/pboyd04/godata/middleware"
+	"gopkg.in/yaml.v3"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type Car struct {
+	BaseModel
+	Name  string
+	Owner string
+	Year  int
+}
+
+// newTestCtrl creates a controller for the model on top of an in memory sqlite database
+func newTestCtrl[T IEntity](t *testing.T) (*CrudCtrl[T], *gin.Engine, *gorm.DB) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("Error opening the database: %s", err)
+	}
+	sqlDb, _ := db.DB()
+	sqlDb.SetMaxOpenConns(1) // every connection gets its own in memory database
+	if err := db.AutoMigrate(new(T)); err != nil {
+		t.Fatalf("Error migrating the database: %s", err)
+	}
+	app := gin.New()
+	app.Use(odata.NewOdataMiddleware(nil).GinMiddleware)
+	conf := NewConfig().WithAutoScaffold(false).WithUI(false).WithDefaultDb(db).WithDefaultRouter(app)
+	ctrl := NewWithOptions[T](db, app.Group("/cars"), conf)
+	return ctrl, app, db
+}
+
+func doRequest(app *gin.Engine, method, path string, form url.Values) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	var req *http.Request
+	if form != nil {
+		req = httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		req = httptest.NewRequest(method, path, nil)
+	}
+	req.Header.Set("Accept", "application/json")
+	app.ServeHTTP(w, req)
+	return w
+}
+
+func doBody(app *gin.Engine, method, path, contentType string, body io.Reader) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, body)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Accept", "application/json")
+	app.ServeHTTP(w, req)
+	return w
+}
+
+func TestUpsert_HooksCanModifyTheItem(t *testing.T) {
+	ctrl, app, db := newTestCtrl[Car](t)
+	calls := []string{}
+	track := func(name string) Hook[Car] {
+		return func(c *gin.Context, item *Car) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+	ctrl.OnBeforeBind(track("beforeBind")).
+		OnAfterBind(track("afterBind")).
+		OnBeforeSave(func(c *gin.Context, item *Car) error {
+			item.Owner = "stamped"
+			item.Name = strings.ToUpper(item.Name)
+			return nil
+		}).
+		OnAfterSave(track("afterSave"))
+
+	w := doRequest(app, "PUT", "/cars/new", url.Values{"Name": {"golf"}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if strings.Join(calls, ",") != "beforeBind,afterBind,afterSave" {
+		t.Errorf("Unexpected hook calls: %v", calls)
+	}
+	var saved Car
+	db.First(&saved)
+	if saved.Name != "GOLF" || saved.Owner != "stamped" {
+		t.Errorf("Expected the hook changes to be saved, got %+v", saved)
+	}
+}
+
+func TestUpsert_HookCanVetoTheOperation(t *testing.T) {
+	ctrl, app, db := newTestCtrl[Car](t)
+	var reported error
+	ctrl.OnBeforeSave(func(c *gin.Context, item *Car) error {
+		return NewHttpError(http.StatusForbidden, "not allowed")
+	}).OnError(func(c *gin.Context, err error) {
+		reported = err
+	})
+
+	w := doRequest(app, "PUT", "/cars/new", url.Values{"Name": {"golf"}})
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403, got %d", w.Code)
+	}
+	var httpErr *HttpError
+	if !errors.As(reported, &httpErr) {
+		t.Errorf("Expected the error hook to receive the veto error, got %v", reported)
+	}
+	var count int64
+	db.Model(&Car{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected nothing to be saved, got %d items", count)
+	}
+}
+
+func TestDelete_HooksReceiveTheDeletedItem(t *testing.T) {
+	ctrl, app, db := newTestCtrl[Car](t)
+	db.Create(&Car{Name: "golf"})
+	var deleted string
+	ctrl.OnBeforeDelete(func(c *gin.Context, item *Car) error {
+		deleted = item.Name
+		return nil
+	})
+
+	w := doRequest(app, "DELETE", "/cars/1", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if deleted != "golf" {
+		t.Errorf("Expected the hook to receive the deleted item, got '%s'", deleted)
+	}
+}
+
+type Plate struct {
+	BaseModel
+	Number string `json:"number" form:"number"`
+	Region string `json:"region" form:"-"`
+}
+
+func TestUpsert_BindsJson(t *testing.T) {
+	_, app, db := newTestCtrl[Plate](t)
+	w := doBody(app, "PUT", "/cars/new", "application/json", strings.NewReader(`{"number":"SK-123","region":"north"}`))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var saved Plate
+	db.First(&saved)
+	if saved.Number != "SK-123" || saved.Region != "north" {
+		t.Errorf("Expected the json body to be bound, got %+v", saved)
+	}
+}
+
+func TestUpsert_BindsFormUsingFormTags(t *testing.T) {
+	_, app, db := newTestCtrl[Plate](t)
+	form := url.Values{"number": {"SK-123"}, "Region": {"north"}}
+	w := doBody(app, "PUT", "/cars/new", "application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var saved Plate
+	db.First(&saved)
+	if saved.Number != "SK-123" || saved.Region != "" {
+		t.Errorf("Expected only the tagged field to be bound, got %+v", saved)
+	}
+}
+
+func TestUpsert_BindsMultipartForm(t *testing.T) {
+	_, app, db := newTestCtrl[Plate](t)
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	_ = mw.WriteField("number", "SK-123")
+	_ = mw.Close()
+	w := doBody(app, "PUT", "/cars/new", mw.FormDataContentType(), body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var saved Plate
+	db.First(&saved)
+	if saved.Number != "SK-123" {
+		t.Errorf("Expected the multipart body to be bound, got %+v", saved)
+	}
+}
+
+func TestUpsert_RejectsUnsupportedContentType(t *testing.T) {
+	_, app, _ := newTestCtrl[Plate](t)
+	w := doBody(app, "PUT", "/cars/new", "text/csv", strings.NewReader("number\nSK-123"))
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("Expected 415, got %d", w.Code)
+	}
+}
+
+func TestPatch_MergePatchKeepsTheOmittedFields(t *testing.T) {
+	_, app, db := newTestCtrl[Car](t)
+	db.Create(&Car{Name: "golf", Owner: "john", Year: 2010})
+
+	w := doBody(app, "PATCH", "/cars/1", MIMEMergePatch, strings.NewReader(`{"Name":"polo","Owner":null}`))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var saved Car
+	db.First(&saved, 1)
+	if saved.Name != "polo" || saved.Owner != "" || saved.Year != 2010 {
+		t.Errorf("Expected only Name and Owner to change, got %+v", saved)
+	}
+}
+
+func TestPatch_JsonPatch(t *testing.T) {
+	_, app, db := newTestCtrl[Car](t)
+	db.Create(&Car{Name: "golf", Owner: "john", Year: 2010})
+
+	patch := `[{"op":"replace","path":"/Year","value":2012},{"op":"test","path":"/Name","value":"golf"}]`
+	w := doBody(app, "PATCH", "/cars/1", MIMEJSONPatch, strings.NewReader(patch))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var saved Car
+	db.First(&saved, 1)
+	if saved.Name != "golf" || saved.Owner != "john" || saved.Year != 2012 {
+		t.Errorf("Expected only Year to change, got %+v", saved)
+	}
+
+	failing := `[{"op":"test","path":"/Name","value":"polo"}]`
+	w = doBody(app, "PATCH", "/cars/1", MIMEJSONPatch, strings.NewReader(failing))
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for a failing test operation, got %d", w.Code)
+	}
+}
+
+func TestPatch_FormUpdatesOnlyTheSubmittedFields(t *testing.T) {
+	_, app, db := newTestCtrl[Car](t)
+	db.Create(&Car{Name: "golf", Owner: "john", Year: 2010})
+
+	w := doRequest(app, "PATCH", "/cars/1", url.Values{"Year": {"2015"}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var saved Car
+	db.First(&saved, 1)
+	if saved.Name != "golf" || saved.Owner != "john" || saved.Year != 2015 {
+		t.Errorf("Expected only Year to change, got %+v", saved)
+	}
+}
+
+func TestPatch_FormClearsTheSubmittedEmptyFields(t *testing.T) {
+	_, app, db := newTestCtrl[Bike](t)
+	db.Create(&Bike{Name: "City", Price: 300, Electric: true})
+
+	// the scaffolded form sends a hidden false before every checkbox
+	w := doRequest(app, "PATCH", "/cars/1", url.Values{"Name": {""}, "Electric": {"false"}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var saved Bike
+	db.First(&saved, 1)
+	if saved.Name != "" || saved.Electric || saved.Price != 300 {
+		t.Errorf("Expected Name and Electric to be cleared, got %+v", saved)
+	}
+
+	doRequest(app, "PATCH", "/cars/1", url.Values{"Electric": {"false", "true"}})
+	db.First(&saved, 1)
+	if !saved.Electric {
+		t.Errorf("Expected the checked box to win over the hidden input, got %+v", saved)
+	}
+}
+
+func TestPatch_RejectsKeyChanges(t *testing.T) {
+	_, app, db := newTestCtrl[Car](t)
+	db.Create(&Car{Name: "golf"})
+
+	w := doBody(app, "PATCH", "/cars/1", MIMEMergePatch, strings.NewReader(`{"ID":5}`))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400, got %d", w.Code)
+	}
+}
+
+func TestDetails_SendsETag(t *testing.T) {
+	ctrl, app, db := newTestCtrl[Car](t)
+	car := Car{Name: "golf"}
+	db.Create(&car)
+
+	w := doRequest(app, "GET", "/cars/1", nil)
+	if w.Header().Get("ETag") == "" || w.Header().Get("ETag") != ctrl.ETag(&car) {
+		t.Errorf("Expected ETag %s, got '%s'", ctrl.ETag(&car), w.Header().Get("ETag"))
+	}
+}
+
+func TestPatch_PreconditionFailedOnStaleETag(t *testing.T) {
+	ctrl, app, db := newTestCtrl[Car](t)
+	car := Car{Name: "golf"}
+	db.Create(&car)
+	etag := ctrl.ETag(&car)
+	db.Model(&car).Update("UpdatedAt", car.UpdatedAt.Add(time.Second)) // someone else changed it
+
+	req := httptest.NewRequest("PATCH", "/cars/1", strings.NewReader(`{"Name":"polo"}`))
+	req.Header.Set("Content-Type", MIMEMergePatch)
+	req.Header.Set("If-Match", etag)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected 412, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("PATCH", "/cars/1", strings.NewReader(`{"Name":"polo"}`))
+	req.Header.Set("Content-Type", MIMEMergePatch)
+	req.Header.Set("If-Match", ctrl.ETag(&car))
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected 200 with the current ETag, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestPatch_ConcurrentWritersWithTheSameETag(t *testing.T) {
+	ctrl, app, db := newTestCtrl[Car](t)
+	car := Car{Name: "golf"}
+	db.Create(&car)
+	etag := ctrl.ETag(&car)
+	// another writer saves the item after it was loaded and checked, but before it is written
+	ctrl.OnBeforeSave(func(c *gin.Context, item *Car) error {
+		return db.Model(&Car{}).Where("id = ?", item.ID).Update("Owner", "john").Error
+	})
+
+	for _, method := range []string{"PATCH", "POST"} {
+		req := httptest.NewRequest(method, "/cars/1", strings.NewReader(`{"Name":"polo"}`))
+		req.Header.Set("Content-Type", gin.MIMEJSON)
+		req.Header.Set("If-Match", etag)
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+		if w.Code != http.StatusPreconditionFailed {
+			t.Errorf("Expected 412 for the %s of the changed item, got %d", method, w.Code)
+		}
+	}
+	var saved Car
+	db.First(&saved, 1)
+	if saved.Name != "golf" || saved.Owner != "john" {
+		t.Errorf("Expected the change of the other writer to be kept, got %+v", saved)
+	}
+}
+
+func TestETag_FullPrecision(t *testing.T) {
+	ctrl, _, _ := newTestCtrl[Car](t)
+	now := time.Now()
+	first, second := Car{}, Car{}
+	first.UpdatedAt, second.UpdatedAt = now, now.Add(time.Microsecond)
+	if ctrl.ETag(&first) == ctrl.ETag(&second) {
+		t.Errorf("Expected different tags for the writes in the same millisecond, got %s", ctrl.ETag(&first))
+	}
+}
+
+func TestDelete_PreconditionFailedOnStaleVersion(t *testing.T) {
+	_, app, db := newTestCtrl[Car](t)
+	db.Create(&Car{Name: "golf"})
+
+	w := doRequest(app, "DELETE", "/cars/1?"+url.Values{ETagFormField: {`"stale"`}}.Encode(), nil)
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected 412, got %d", w.Code)
+	}
+	var count int64
+	db.Model(&Car{}).Count(&count)
+	if count != 1 {
+		t.Errorf("Expected the item to be kept")
+	}
+}
+
+func TestDetails_NotFoundAsProblem(t *testing.T) {
+	_, app, _ := newTestCtrl[Car](t)
+
+	w := doRequest(app, "GET", "/cars/42", nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404, got %d", w.Code)
+	}
+	if w.Header().Get("Content-Type") != MIMEProblemJSON {
+		t.Errorf("Expected %s, got %s", MIMEProblemJSON, w.Header().Get("Content-Type"))
+	}
+	var problem Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("Expected a problem document, got %s", w.Body.String())
+	}
+	if problem.Status != http.StatusNotFound || problem.Instance != "/cars/42" {
+		t.Errorf("Unexpected problem %+v", problem)
+	}
+}
+
+func TestDelete_NotFound(t *testing.T) {
+	_, app, _ := newTestCtrl[Car](t)
+
+	w := doRequest(app, "DELETE", "/cars/42", nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404, got %d", w.Code)
+	}
+}
+
+func TestDetails_InvalidID(t *testing.T) {
+	_, app, _ := newTestCtrl[Car](t)
+
+	w := doRequest(app, "GET", "/cars/abc", nil)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400, got %d", w.Code)
+	}
+}
+
+type Country struct {
+	Code string `gorm:"primaryKey"`
+	Name string
+}
+
+type Membership struct {
+	TeamID uint   `gorm:"primaryKey;autoIncrement:false"`
+	UserID string `gorm:"primaryKey"`
+	Role   string
+}
+
+func TestKeys_StringKey(t *testing.T) {
+	_, app, db := newTestCtrl[Country](t)
+	db.Create(&Country{Code: "mk", Name: "Macedonia"})
+
+	w := doRequest(app, "POST", "/cars/mk", url.Values{"Name": {"North Macedonia"}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("HX-Redirect") != "/cars/mk" {
+		t.Errorf("Expected redirect to /cars/mk, got %s", w.Header().Get("HX-Redirect"))
+	}
+	var countries []Country
+	db.Find(&countries)
+	if len(countries) != 1 || countries[0].Name != "North Macedonia" {
+		t.Errorf("Expected the country to be updated in place, got %+v", countries)
+	}
+}
+
+func TestKeys_EscapedValues(t *testing.T) {
+	_, app, db := newTestCtrl[Country](t)
+	db.Create(&Country{Code: "a,b c%", Name: "Nowhere"})
+
+	w := doRequest(app, "POST", "/cars/a%2Cb%20c%25", url.Values{"Name": {"Somewhere"}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("HX-Redirect") != "/cars/a%2Cb%20c%25" {
+		t.Errorf("Expected the key to be escaped in the redirect, got %s", w.Header().Get("HX-Redirect"))
+	}
+	// the single field keys are not split, even if the separator is not escaped
+	if w := doRequest(app, "GET", "/cars/a,b%20c%25", nil); w.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	_, app, db = newTestCtrl[Membership](t)
+	db.Create(&Membership{TeamID: 1, UserID: "jane,doe", Role: "member"})
+	if w := doRequest(app, "GET", "/cars/1,jane%2Cdoe", nil); w.Code != http.StatusOK {
+		t.Errorf("Expected the escaped separator to be part of the value, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestKeys_CompositeKey(t *testing.T) {
+	_, app, db := newTestCtrl[Membership](t)
+	db.Create(&Membership{TeamID: 1, UserID: "john", Role: "member"})
+	db.Create(&Membership{TeamID: 1, UserID: "jane", Role: "member"})
+
+	w := doBody(app, "PATCH", "/cars/1,jane", MIMEMergePatch, strings.NewReader(`{"Role":"admin"}`))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var jane, john Membership
+	db.First(&jane, "user_id = ?", "jane")
+	db.First(&john, "user_id = ?", "john")
+	if jane.Role != "admin" || john.Role != "member" {
+		t.Errorf("Expected only jane to be updated, got %+v %+v", jane, john)
+	}
+	if w := doRequest(app, "GET", "/cars/1", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an incomplete key, got %d", w.Code)
+	}
+}
+
+func TestUpsert_UpdatesInPlace(t *testing.T) {
+	_, app, db := newTestCtrl[Car](t)
+	db.Create(&Car{Name: "golf"})
+
+	w := doRequest(app, "POST", "/cars/1", url.Values{"Name": {"polo"}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var cars []Car
+	db.Find(&cars)
+	if len(cars) != 1 || cars[0].Name != "polo" {
+		t.Errorf("Expected the car to be updated in place, got %+v", cars)
+	}
+}
+
+func TestTrash_RestoreAndPurge(t *testing.T) {
+	_, app, db := newTestCtrl[Car](t)
+	db.Create(&Car{Name: "golf"})
+	db.Create(&Car{Name: "polo"})
+
+	doRequest(app, "DELETE", "/cars/1", nil)
+	doRequest(app, "DELETE", "/cars/2", nil)
+	w := doRequest(app, "GET", "/cars/trash", nil)
+	var trash listResponse[Car]
+	_ = json.Unmarshal(w.Body.Bytes(), &trash)
+	if len(trash.Items) != 2 {
+		t.Fatalf("Expected 2 items in the trash, got %s", w.Body.String())
+	}
+
+	if w := doRequest(app, "POST", "/cars/1/restore", nil); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doRequest(app, "GET", "/cars/1", nil); w.Code != http.StatusOK {
+		t.Errorf("Expected the restored item to be available, got %d", w.Code)
+	}
+	if w := doRequest(app, "POST", "/cars/1/restore", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 when restoring an item that is not in the trash, got %d", w.Code)
+	}
+
+	if w := doRequest(app, "DELETE", "/cars/2/purge", nil); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var count int64
+	db.Unscoped().Model(&Car{}).Count(&count)
+	if count != 1 {
+		t.Errorf("Expected the purged item to be permanently deleted, got %d items", count)
+	}
+}
+
+func TestDelete_HardDelete(t *testing.T) {
+	ctrl, app, db := newTestCtrl[Car](t)
+	ctrl.Config.(*Config).WithHardDelete(true)
+	db.Create(&Car{Name: "golf"})
+
+	doRequest(app, "DELETE", "/cars/1", nil)
+	var count int64
+	db.Unscoped().Model(&Car{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected the item to be permanently deleted, got %d items", count)
+	}
+}
+
+func TestTrash_NotRegisteredWithoutSoftDeletes(t *testing.T) {
+	_, app, _ := newTestCtrl[Country](t)
+	if w := doRequest(app, "GET", "/cars/trash", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404, got %d", w.Code)
+	}
+}
+
+func TestBulkCreate_AtomicRollsBackOnFailure(t *testing.T) {
+	ctrl, app, db := newTestCtrl[Car](t)
+	ctrl.OnBeforeSave(func(c *gin.Context, item *Car) error {
+		if item.Name == "" {
+			return errors.New("Name is required")
+		}
+		return nil
+	})
+
+	w := doBody(app, "PUT", "/cars/bulk", "application/json", strings.NewReader(`[{"Name":"golf"},{"Name":""},{"Name":"polo"}]`))
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected 422, got %d: %s", w.Code, w.Body.String())
+	}
+	var report BulkReport
+	_ = json.Unmarshal(w.Body.Bytes(), &report)
+	if report.Committed || report.Failed != 1 || report.Results[1].Status != http.StatusBadRequest {
+		t.Errorf("Expected the second item to fail, got %+v", report)
+	}
+	var count int64
+	db.Model(&Car{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected nothing to be saved, got %d items", count)
+	}
+}
+
+func TestBulkCreate_ContinueCommitsTheSucceededItems(t *testing.T) {
+	ctrl, app, db := newTestCtrl[Car](t)
+	ctrl.OnAfterSave(func(c *gin.Context, item *Car) error {
+		if item.Name == "polo" {
+			return errors.New("polo is not allowed")
+		}
+		return nil
+	})
+
+	w := doBody(app, "PUT", "/cars/bulk?mode=continue", "application/json", strings.NewReader(`[{"Name":"golf"},{"Name":"polo"},{"Name":"passat"}]`))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var report BulkReport
+	_ = json.Unmarshal(w.Body.Bytes(), &report)
+	if !report.Committed || report.Succeeded != 2 || report.Failed != 1 {
+		t.Errorf("Expected 2 items to succeed and 1 to fail, got %+v", report)
+	}
+	var cars []Car
+	db.Order("name").Find(&cars)
+	if len(cars) != 2 || cars[0].Name != "golf" || cars[1].Name != "passat" {
+		t.Errorf("Expected the failed item to be rolled back, got %+v", cars)
+	}
+}
+
+func TestBulkUpsert_UpdatesAndCreates(t *testing.T) {
+	_, app, db := newTestCtrl[Car](t)
+	db.Create(&Car{Name: "golf"})
+
+	w := doBody(app, "POST", "/cars/bulk", "application/json", strings.NewReader(`[{"ID":1,"Name":"polo"},{"Name":"passat"}]`))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var cars []Car
+	db.Order("id").Find(&cars)
+	if len(cars) != 2 || cars[0].Name != "polo" || cars[1].Name != "passat" {
+		t.Errorf("Expected the first item to be updated and the second created, got %+v", cars)
+	}
+}
+
+func TestBulkUpsert_ChecksTheVersionAndRunsTheBindHooks(t *testing.T) {
+	ctrl, app, db := newTestCtrl[Car](t)
+	binds := 0
+	ctrl.OnBeforeBind(func(c *gin.Context, item *Car) error {
+		binds++
+		return nil
+	})
+	ctrl.OnAfterBind(func(c *gin.Context, item *Car) error {
+		item.Owner = "bound"
+		return nil
+	})
+	db.Create(&Car{Name: "golf"})
+	var car Car
+	db.First(&car)
+
+	stale := car
+	stale.Name, stale.UpdatedAt = "polo", car.UpdatedAt.Add(-time.Second)
+	body, _ := json.Marshal([]Car{stale})
+	w := doBody(app, "POST", "/cars/bulk", "application/json", bytes.NewReader(body))
+	var report BulkReport
+	_ = json.Unmarshal(w.Body.Bytes(), &report)
+	if w.Code != http.StatusUnprocessableEntity || report.Results[0].Status != http.StatusPreconditionFailed {
+		t.Fatalf("Expected 412 for a stale version, got %d: %s", w.Code, w.Body.String())
+	}
+
+	car.Name = "polo"
+	body, _ = json.Marshal([]Car{car})
+	if w := doBody(app, "POST", "/cars/bulk", "application/json", bytes.NewReader(body)); w.Code != http.StatusOK {
+		t.Fatalf("Expected the current version to be saved, got %d: %s", w.Code, w.Body.String())
+	}
+	db.First(&car)
+	if car.Name != "polo" || car.Owner != "bound" || binds != 2 {
+		t.Errorf("Expected the item to be saved with the bind hooks, got %+v after %d binds", car, binds)
+	}
+}
+
+func TestBulkDelete(t *testing.T) {
+	_, app, db := newTestCtrl[Car](t)
+	db.Create(&Car{Name: "golf"})
+	db.Create(&Car{Name: "polo"})
+	db.Create(&Car{Name: "passat"})
+
+	w := doRequest(app, "DELETE", "/cars/bulk?id=1&id=2&id=9", nil)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected 422 when an item is missing, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = doRequest(app, "DELETE", "/cars/bulk?mode=continue&id=1&id=2&id=9", nil)
+	var report BulkReport
+	_ = json.Unmarshal(w.Body.Bytes(), &report)
+	if report.Succeeded != 2 || report.Results[2].Status != http.StatusNotFound {
+		t.Errorf("Expected the missing item to be reported as not found, got %+v", report)
+	}
+
+	w = doBody(app, "DELETE", "/cars/bulk", "application/json", strings.NewReader(`[3]`))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var count int64
+	db.Model(&Car{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected all the items to be deleted, got %d items", count)
+	}
+}
+
+type Team struct {
+	ID      uint
+	Name    string
+	Players []Player
+}
+
+type Player struct {
+	ID     uint
+	Name   string
+	TeamID uint
+	Team   *Team
+}
+
+func TestList_ExpandPreloadsTheRelations(t *testing.T) {
+	_, app, db := newTestCtrl[Team](t)
+	_ = db.AutoMigrate(new(Player))
+	db.Create(&Team{Name: "red", Players: []Player{{Name: "ann"}, {Name: "bob"}}})
+
+	w := doRequest(app, "GET", "/cars/?$expand=players", nil)
+	var list listResponse[Team]
+	_ = json.Unmarshal(w.Body.Bytes(), &list)
+	if len(list.Items) != 1 || len(list.Items[0].Players) != 2 {
+		t.Errorf("Expected the players to be preloaded, got %s", w.Body.String())
+	}
+
+	w = doRequest(app, "GET", "/cars/1", nil)
+	var details map[string]Team
+	_ = json.Unmarshal(w.Body.Bytes(), &details)
+	if len(details["Team"].Players) != 0 {
+		t.Errorf("Expected the players not to be loaded without $expand, got %s", w.Body.String())
+	}
+
+	if w := doRequest(app, "GET", "/cars/1?$expand=coach", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown relation, got %d", w.Code)
+	}
+}
+
+func TestRelated_HasManyIsScopedByTheParent(t *testing.T) {
+	_, app, db := newTestCtrl[Team](t)
+	_ = db.AutoMigrate(new(Player))
+	db.Create(&Team{Name: "red", Players: []Player{{Name: "ann"}, {Name: "bob"}}})
+	db.Create(&Team{Name: "blue", Players: []Player{{Name: "cid"}}})
+
+	w := doRequest(app, "GET", "/cars/2/players", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var list listResponse[Player]
+	_ = json.Unmarshal(w.Body.Bytes(), &list)
+	if len(list.Items) != 1 || list.Items[0].Name != "cid" {
+		t.Errorf("Expected only the players of the team, got %s", w.Body.String())
+	}
+	if w := doRequest(app, "GET", "/cars/9/players", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing parent, got %d", w.Code)
+	}
+}
+
+func TestRelated_HasManyIsPagedAndFiltered(t *testing.T) {
+	ctrl, app, db := newTestCtrl[Team](t)
+	ctrl.Config.(*Config).WithPageSize(2, 2)
+	_ = db.AutoMigrate(new(Player))
+	db.Create(&Team{Name: "red", Players: []Player{{Name: "ann"}, {Name: "bob"}, {Name: "cid"}}})
+
+	w := doRequest(app, "GET", "/cars/1/players?$top=100&$count=true&$orderby=Name%20desc", nil)
+	var list listResponse[Player]
+	_ = json.Unmarshal(w.Body.Bytes(), &list)
+	if len(list.Items) != 2 || list.Items[0].Name != "cid" || list.Count == nil || *list.Count != 3 || list.Links.Next == "" {
+		t.Errorf("Expected the first page limited to the maximum page size, got %s", w.Body.String())
+	}
+
+	w = doRequest(app, "GET", "/cars/1/players?$filter=Name%20eq%20'bob'", nil)
+	list = listResponse[Player]{}
+	_ = json.Unmarshal(w.Body.Bytes(), &list)
+	if len(list.Items) != 1 || list.Items[0].Name != "bob" {
+		t.Errorf("Expected the players to be filtered, got %s", w.Body.String())
+	}
+}
+
+func TestRelated_HasManyHTML(t *testing.T) {
+	ctrl, app, db := newTestCtrl[Team](t)
+	ctrl.Config.(*Config).WithUI(true).WithPageSize(2, 2)
+	_ = db.AutoMigrate(new(Player))
+	db.Create(&Team{Name: "red", Players: []Player{{Name: "ann"}, {Name: "bob"}, {Name: "cid"}}})
+	dir := t.TempDir()
+	GenRelatedTmpl(Player{}, dir)
+	app.HTMLRender = ginrender.HTMLProduction{Template: template.Must(template.ParseFiles(filepath.Join(dir, "player-related.html")))}
+
+	w := doAs(app, "", "GET", "/cars/1/players?$orderby=Name%20desc", "Accept", "text/html", "HX-Request", "true")
+	body := w.Body.String()
+	if w.Code != http.StatusOK || !strings.Contains(body, "<td>cid</td>") || !strings.Contains(body, "<td>bob</td>") || strings.Contains(body, "<td>ann</td>") {
+		t.Fatalf("Expected the first page of the players, got %d %s", w.Code, body)
+	}
+	if !strings.Contains(body, "3 items") || !strings.Contains(body, `hx-get="/cars/1/players?%24orderby=Name&#43;desc&amp;%24skip=2&amp;%24top=2"`) {
+		t.Errorf("Expected the count and the link of the next page, got %s", body)
+	}
+	if !strings.HasSuffix(strings.TrimSpace(body), "</section>") || strings.Contains(body, "/import") || strings.Contains(body, "/export") {
+		t.Errorf("Expected the whole page without the import and export of the list, got %s", body)
+	}
+}
+
+func TestRelated_BelongsTo(t *testing.T) {
+	_, app, db := newTestCtrl[Player](t)
+	_ = db.AutoMigrate(new(Team))
+	db.Create(&Team{Name: "red", Players: []Player{{Name: "ann"}}})
+
+	w := doRequest(app, "GET", "/cars/1/team", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var details map[string]Team
+	_ = json.Unmarshal(w.Body.Bytes(), &details)
+	if details["Team"].Name != "red" {
+		t.Errorf("Expected the team of the player, got %s", w.Body.String())
+	}
+}
+
+func TestList_SelectTrimsTheResponse(t *testing.T) {
+	_, app, db := newTestCtrl[Plate](t)
+	db.Create(&Plate{Number: "SK-123", Region: "north"})
+
+	w := doRequest(app, "GET", "/cars/?$select=Number", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var list listResponse[map[string]interface{}]
+	_ = json.Unmarshal(w.Body.Bytes(), &list)
+	items := list.Items
+	if len(items) != 1 || items[0]["number"] != "SK-123" || items[0]["ID"] == nil || len(items[0]) != 2 {
+		t.Errorf("Expected only the key and the selected field, got %s", w.Body.String())
+	}
+
+	w = doRequest(app, "GET", "/cars/1?$select=region", nil)
+	var details map[string]map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &details)
+	if details["Plate"]["region"] != "north" || len(details["Plate"]) != 2 {
+		t.Errorf("Expected only the key and the selected field, got %s", w.Body.String())
+	}
+
+	if w := doRequest(app, "GET", "/cars/?$select=Number,Owner", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown field, got %d", w.Code)
+	}
+}
+
+func TestList_SelectWithExpand(t *testing.T) {
+	_, app, db := newTestCtrl[Player](t)
+	_ = db.AutoMigrate(new(Team))
+	db.Create(&Team{Name: "red", Players: []Player{{Name: "ann"}}})
+
+	w := doRequest(app, "GET", "/cars/?$select=Name&$expand=Team", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var list listResponse[Player]
+	_ = json.Unmarshal(w.Body.Bytes(), &list)
+	if players := list.Items; len(players) != 1 || players[0].Team == nil || players[0].Team.Name != "red" {
+		t.Errorf("Expected the team to be preloaded through the foreign key, got %s", w.Body.String())
+	}
+
+	_, app, db = newTestCtrl[Team](t)
+	_ = db.AutoMigrate(new(Player))
+	db.Create(&Team{Name: "red", Players: []Player{{Name: "ann"}, {Name: "bob"}}})
+	w = doRequest(app, "GET", "/cars/1?$select=Name&$expand=Players", nil)
+	var details map[string]Team
+	_ = json.Unmarshal(w.Body.Bytes(), &details)
+	if len(details["Team"].Players) != 2 {
+		t.Errorf("Expected the players to be preloaded, got %s", w.Body.String())
+	}
+}
+
+// ownerPolicy lets the user from the X-User header see and change only their own cars
+func ownerPolicy() *Policy[Car] {
+	return &Policy[Car]{
+		AuthorizeFunc: func(c *gin.Context, action Action, item *Car) error {
+			if item != nil && item.Owner != c.GetHeader("X-User") {
+				return errors.New("not your car")
+			}
+			return nil
+		},
+		ScopeFunc: func(c *gin.Context, db *gorm.DB) *gorm.DB {
+			if c.GetHeader("X-Admin") != "" {
+				return db
+			}
+			return db.Where("owner = ?", c.GetHeader("X-User"))
+		},
+	}
+}
+
+// listResponse is the json envelope of the lists
+type listResponse[T any] struct {
+	Items []T       `json:"items"`
+	Count *int64    `json:"count"`
+	Links PageLinks `json:"links"`
+	Page  Page      `json:"page"`
+}
+
+func doAs(app *gin.Engine, user, method, path string, headers ...string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-User", user)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	app.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuthorizer_ScopesTheQueries(t *testing.T) {
+	ctrl, app, db := newTestCtrl[Car](t)
+	ctrl.WithAuthorizer(ownerPolicy())
+	db.Create(&Car{Name: "golf", Owner: "ann"})
+	db.Create(&Car{Name: "polo", Owner: "bob"})
+
+	w := doAs(app, "ann", "GET", "/cars/")
+	var list listResponse[Car]
+	_ = json.Unmarshal(w.Body.Bytes(), &list)
+	if len(list.Items) != 1 || list.Items[0].Name != "golf" {
+		t.Errorf("Expected only the cars of the user, got %s", w.Body.String())
+	}
+	if w := doAs(app, "ann", "GET", "/cars/2"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an item outside of the scope, got %d", w.Code)
+	}
+	if w := doAs(app, "ann", "DELETE", "/cars/2"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 when deleting an item outside of the scope, got %d", w.Code)
+	}
+}
+
+func TestAuthorizer_ForbidsTheAction(t *testing.T) {
+	ctrl, app, db := newTestCtrl[Car](t)
+	ctrl.WithAuthorizer(ownerPolicy())
+	db.Create(&Car{Name: "polo", Owner: "bob"})
+
+	if w := doAs(app, "ann", "GET", "/cars/1", "X-Admin", "1"); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403, got %d", w.Code)
+	}
+	if w := doAs(app, "ann", "DELETE", "/cars/1", "X-Admin", "1"); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403, got %d", w.Code)
+	}
+	var count int64
+	db.Model(&Car{}).Count(&count)
+	if count != 1 {
+		t.Errorf("Expected the item not to be deleted, got %d items", count)
+	}
+	if w := doAs(app, "bob", "DELETE", "/cars/1"); w.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestAuthorizer_ChecksTheSubmittedItem(t *testing.T) {
+	ctrl, app, db := newTestCtrl[Car](t)
+	ctrl.WithAuthorizer(ownerPolicy())
+	db.Create(&Car{Name: "polo", Owner: "bob"})
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("X-User", "bob")
+		app.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := send("PATCH", "/cars/1", `{"Owner":"ann"}`); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 when giving the item away with a patch, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send("POST", "/cars/1", `{"Name":"polo","Owner":"ann"}`); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 when giving the item away with an update, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send("PUT", "/cars/new", `{"Name":"golf","Owner":"ann"}`); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 when creating an item for another user, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send("POST", "/cars/bulk", `[{"ID":1,"Name":"polo","Owner":"ann"},{"Name":"golf","Owner":"ann"}]`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for a bulk save of items of another user, got %d: %s", w.Code, w.Body.String())
+	}
+	var cars []Car
+	db.Find(&cars)
+	if len(cars) != 1 || cars[0].Owner != "bob" {
+		t.Errorf("Expected the items not to be changed, got %+v", cars)
+	}
+	if w := send("PATCH", "/cars/1", `{"Name":"golf"}`); w.Code != http.StatusOK {
+		t.Errorf("Expected 200 for an allowed patch, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestAuthorizer_TemplatesCanCheckTheActions(t *testing.T) {
+	ctrl, app, db := newTestCtrl[Car](t)
+	ctrl.WithAuthorizer(ownerPolicy())
+	ctrl.Config.(*Config).WithUI(true)
+	app.HTMLRender = ginrender.HTMLProduction{Template: template.Must(template.New("car-list.html").Parse(
+		`{{range .CarList}}{{.Name}}{{if call $.Can "update" .}}(edit){{end}};{{end}}`))}
+	db.Create(&Car{Name: "golf", Owner: "ann"})
+	db.Create(&Car{Name: "polo", Owner: "bob"})
+
+	w := doAs(app, "ann", "GET", "/cars/", "Accept", "text/html", "X-Admin", "1", "HX-Request", "true")
+	if w.Body.String() != "golf(edit);polo;" {
+		t.Errorf("Expected the edit link only on the allowed items, got %s", w.Body.String())
+	}
+}
+
+func TestActions_ReadOnlyRejectsTheChanges(t *testing.T) {
+	ctrl, app, db := newTestCtrl[Car](t)
+	ctrl.ReadOnly()
+	db.Create(&Car{Name: "golf"})
+
+	if w := doRequest(app, "GET", "/cars/1", nil); w.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d", w.Code)
+	}
+	if w := doRequest(app, "PUT", "/cars/new", url.Values{"Name": {"polo"}}); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 on create, got %d", w.Code)
+	}
+	if w := doRequest(app, "POST", "/cars/1", url.Values{"Name": {"polo"}}); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 on update, got %d", w.Code)
+	}
+	if w := doRequest(app, "DELETE", "/cars/bulk?id=1", nil); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 on bulk delete, got %d", w.Code)
+	}
+	var count int64
+	db.Model(&Car{}).Where("name = ?", "golf").Count(&count)
+	if count != 1 {
+		t.Errorf("Expected the item not to be changed, got %d items", count)
+	}
+}
+
+func TestActions_TemplatesHideTheDisabledActions(t *testing.T) {
+	ctrl, app, db := newTestCtrl[Car](t)
+	ctrl.WithoutActions(ActionDelete)
+	ctrl.Config.(*Config).WithUI(true)
+	app.HTMLRender = ginrender.HTMLProduction{Template: template.Must(template.New("car-list.html").Parse(
+		`{{if call $.HasAction "delete"}}(bulk){{end}}{{range .CarList}}{{.Name}}{{if call $.Can "update" .}}(edit){{end}}{{if call $.Can "delete" .}}(delete){{end}};{{end}}`))}
+	db.Create(&Car{Name: "golf"})
+
+	w := doAs(app, "", "GET", "/cars/", "Accept", "text/html", "HX-Request", "true")
+	if w.Body.String() != "golf(edit);" {
+		t.Errorf("Expected only the edit link, got %s", w.Body.String())
+	}
+	if w := doRequest(app, "DELETE", "/cars/1", nil); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405, got %d", w.Code)
+	}
+}
+
+func TestCustomAction_Member(t *testing.T) {
+	ctrl, app, db := newTestCtrl[Car](t)
+	ctrl.WithAuthorizer(ownerPolicy())
+	ctrl.WithCustomAction(CustomAction{Name: "sell", Member: true, Confirm: "Sell the car?"}, func(c *gin.Context, car *Car) error {
+		return db.Model(car).Update("Owner", c.Query("to")).Error
+	})
+	db.Create(&Car{Name: "golf", Owner: "ann"})
+
+	if w := doAs(app, "bob", "POST", "/cars/1/sell?to=bob"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an item out of the scope, got %d", w.Code)
+	}
+	w := doAs(app, "ann", "POST", "/cars/1/sell?to=bob")
+	if w.Code != http.StatusOK || w.Header().Get("HX-Redirect") != "/cars/1" {
+		t.Errorf("Expected 200 with a redirect to the item, got %d %s", w.Code, w.Header().Get("HX-Redirect"))
+	}
+	var car Car
+	db.First(&car, 1)
+	if car.Owner != "bob" {
+		t.Errorf("Expected the car to be sold, got %s", car.Owner)
+	}
+	if w := doAs(app, "ann", "GET", "/cars/1/sell"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected only the POST route, got %d", w.Code)
+	}
+}
+
+func TestCustomAction_Collection(t *testing.T) {
+	ctrl, app, db := newTestCtrl[Car](t)
+	ctrl.WithCustomAction(CustomAction{Name: "recalculate"}, func(c *gin.Context, car *Car) error {
+		if car != nil {
+			t.Errorf("Expected no item for a collection action")
+		}
+		c.JSON(http.StatusOK, gin.H{"updated": db.Model(&Car{}).Where("1 = 1").Update("Year", 2000).RowsAffected})
+		return nil
+	})
+	db.Create(&Car{Name: "golf"})
+	db.Create(&Car{Name: "polo"})
+
+	w := doRequest(app, "POST", "/cars/recalculate", nil)
+	if w.Code != http.StatusOK || w.Body.String() != `{"updated":2}` {
+		t.Errorf("Expected the response of the handler, got %d %s", w.Code, w.Body.String())
+	}
+	ctrl.WithoutActions(Action("recalculate"))
+	if w := doRequest(app, "POST", "/cars/recalculate", nil); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for a disabled action, got %d", w.Code)
+	}
+}
+
+func TestCustomAction_MustBeListedInTheActions(t *testing.T) {
+	handler := func(c *gin.Context, car *Car) error { return nil }
+	for name, setup := range map[string]func(ctrl *CrudCtrl[Car]){
+		"before": func(ctrl *CrudCtrl[Car]) {
+			ctrl.WithCustomAction(CustomAction{Name: "recalculate"}, handler).ReadOnly()
+		},
+		"after": func(ctrl *CrudCtrl[Car]) {
+			ctrl.ReadOnly().WithCustomAction(CustomAction{Name: "recalculate"}, handler)
+		},
+	} {
+		ctrl, app, _ := newTestCtrl[Car](t)
+		setup(ctrl)
+		if w := doRequest(app, "POST", "/cars/recalculate", nil); w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s: Expected 405 for an action that is not listed, got %d", name, w.Code)
+		}
+		ctrl.WithActions(append(ReadOnlyActions, Action("recalculate"))...)
+		if w := doRequest(app, "POST", "/cars/recalculate", nil); w.Code != http.StatusOK {
+			t.Errorf("%s: Expected 200 for a listed action, got %d", name, w.Code)
+		}
+	}
+
+	ctrl, app, _ := newTestCtrl[Car](t)
+	ctrl.WithoutActions(ActionDelete).WithCustomAction(CustomAction{Name: "recalculate"}, handler)
+	if w := doRequest(app, "POST", "/cars/recalculate", nil); w.Code != http.StatusOK {
+		t.Errorf("Expected 200 for an action that is not disabled, got %d", w.Code)
+	}
+}
+
+func TestCustomAction_TemplatesRenderTheButtons(t *testing.T) {
+	ctrl, app, db := newTestCtrl[Car](t)
+	ctrl.Config.(*Config).WithUI(true)
+	ctrl.WithCustomAction(CustomAction{Name: "sell", Label: "Sell", Member: true}, func(c *gin.Context, car *Car) error { return nil })
+	ctrl.WithCustomAction(CustomAction{Name: "report", Method: "get"}, func(c *gin.Context, car *Car) error { return nil })
+	app.HTMLRender = ginrender.HTMLProduction{Template: template.Must(template.New("car-list.html").Parse(
+		`{{range $.CustomActions}}{{.Verb}} {{.Label}}{{if .Member}}(member){{end}};{{end}}`))}
+	db.Create(&Car{Name: "golf"})
+
+	w := doAs(app, "", "GET", "/cars/", "Accept", "text/html", "HX-Request", "true")
+	if w.Body.String() != "post Sell(member);get report;" {
+		t.Errorf("Expected the custom actions, got %s", w.Body.String())
+	}
+}
+
+func TestCustomAction_OpenAPI(t *testing.T) {
+	ctrl, _, _ := newTestCtrl[Car](t)
+	ctrl.WithCustomAction(CustomAction{Name: "sell", Member: true}, func(c *gin.Context, car *Car) error { return nil })
+	fileName := filepath.Join(t.TempDir(), "openapi.yaml")
+	GenOpenAPI(fileName, []ICrudCtrl{ctrl})
+
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("Expected the API description to be generated: %s", err)
+	}
+	if !strings.Contains(string(content), "/cars/{id}/sell:") || !strings.Contains(string(content), "  /cars/{id}:") {
+		t.Errorf("Expected the routes of the controller and its actions, got %s", content)
+	}
+}
+
+func TestOpenAPI_ListsTheExposedRoutes(t *testing.T) {
+	cars, app, db := newAuditedCtrl(t)
+	cars.WithoutActions(ActionDelete).
+		WithCustomAction(CustomAction{Name: "sell", Member: true}, func(c *gin.Context, car *Car) error { return nil })
+	teams := NewWithOptions[Team](db, app.Group("/teams"), cars.Config).ReadOnly().
+		WithCustomAction(CustomAction{Name: "recalculate"}, func(c *gin.Context, team *Team) error { return nil })
+	fileName := filepath.Join(t.TempDir(), "openapi.yaml")
+	GenOpenAPI(fileName, []ICrudCtrl{cars, teams})
+
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("Expected the API description to be generated: %s", err)
+	}
+	var doc struct {
+		Paths map[string]map[string]interface{}
+	}
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		t.Fatalf("Expected a valid yaml: %s\n%s", err, content)
+	}
+	expected := map[string][]string{
+		"/cars/":              {"get"},
+		"/cars/trash":         {"get"},
+		"/cars/bulk":          {"put", "post"},
+		"/cars/{id}":          {"parameters", "get", "post", "patch"},
+		"/cars/{id}/restore":  {"parameters", "post"},
+		"/cars/{id}/history":  {"parameters", "get"},
+		"/cars/{id}/sell":     {"parameters", "post"},
+		"/teams/":             {"get"},
+		"/teams/{id}":         {"parameters", "get"},
+		"/teams/{id}/players": {"parameters", "get"},
+	}
+	for path, operations := range expected {
+		item, ok := doc.Paths[path]
+		if !ok {
+			t.Errorf("Expected the route %s", path)
+			continue
+		}
+		if len(item) != len(operations) {
+			t.Errorf("Expected %v on %s, got %v", operations, path, item)
+		}
+		for _, operation := range operations {
+			if _, ok := item[operation]; !ok {
+				t.Errorf("Expected %s on %s", operation, path)
+			}
+		}
+	}
+	for _, path := range []string{"/cars/{id}/purge", "/teams/new", "/teams/bulk", "/teams/import", "/teams/trash", "/teams/recalculate"} {
+		if _, ok := doc.Paths[path]; ok {
+			t.Errorf("Expected no route %s for the disabled actions", path)
+		}
+	}
+}
+
+func TestExport_CSV(t *testing.T) {
+	_, app, db := newTestCtrl[Car](t)
+	ExportBatchSize = 1
+	defer func() { ExportBatchSize = 500 }()
+	db.Create(&Car{Name: "golf", Year: 2001})
+	db.Create(&Car{Name: "polo", Year: 2003})
+	db.Create(&Car{Name: "up", Year: 1999})
+
+	w := doRequest(app, "GET", "/cars/export?"+url.Values{"$filter": {"Year gt 2000"}, "$orderby": {"Year desc"}, "$select": {"Name"}}.Encode(), nil)
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("Expected a csv, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	if w.Body.String() != "ID,Name\n2,polo\n1,golf\n" {
+		t.Errorf("Expected the filtered and ordered rows, got %q", w.Body.String())
+	}
+}
+
+func TestExport_NDJSON(t *testing.T) {
+	_, app, db := newTestCtrl[Car](t)
+	db.Create(&Car{Name: "golf"})
+	db.Create(&Car{Name: "polo"})
+
+	w := doRequest(app, "GET", "/cars/export?format=ndjson", nil)
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected a line per item, got %s", w.Body.String())
+	}
+	var car Car
+	if err := json.Unmarshal([]byte(lines[1]), &car); err != nil || car.Name != "polo" {
+		t.Errorf("Expected the items as json, got %s", lines[1])
+	}
+	if w := doRequest(app, "GET", "/cars/export?format=pdf", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown format, got %d", w.Code)
+	}
+}
+
+func TestExport_XLSX(t *testing.T) {
+	_, app, db := newTestCtrl[Car](t)
+	db.Create(&Car{Name: "golf & co", Year: 2001})
+
+	w := doRequest(app, "GET", "/cars/export?format=xlsx&$select=Name,Year", nil)
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("Expected a zip archive: %s", err)
+	}
+	for _, f := range archive.File {
+		if f.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		r, _ := f.Open()
+		sheet, _ := io.ReadAll(r)
+		expected := `<row><c t="inlineStr"><is><t>ID</t></is></c><c t="inlineStr"><is><t>Name</t></is></c><c t="inlineStr"><is><t>Year</t></is></c></row>` +
+			`<row><c><v>1</v></c><c t="inlineStr"><is><t>golf &amp; co</t></is></c><c><v>2001</v></c></row>`
+		if !strings.Contains(string(sheet), expected) {
+			t.Errorf("Expected the rows in the sheet, got %s", sheet)
+		}
+		return
+	}
+	t.Errorf("Expected the workbook to have a sheet")
+}
+
+func TestExport_EscapesTheFormulas(t *testing.T) {
+	_, app, db := newTestCtrl[Car](t)
+	db.Create(&Car{Name: "=HYPERLINK(\"http://x\")", Owner: "-2+3", Year: -1})
+	db.Create(&Car{Name: "@SUM(A1)", Owner: "\tann", Year: 2001})
+
+	w := doRequest(app, "GET", "/cars/export?$select=Name,Owner,Year", nil)
+	expected := "ID,Name,Owner,Year\n1,\"'=HYPERLINK(\"\"http://x\"\")\",'-2+3,-1\n2,'@SUM(A1),'\tann,2001\n"
+	if w.Body.String() != expected {
+		t.Errorf("Expected the formulas to be escaped, got %q", w.Body.String())
+	}
+
+	w = doRequest(app, "GET", "/cars/export?format=xlsx&$select=Name,Year", nil)
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("Expected a zip archive: %s", err)
+	}
+	for _, f := range archive.File {
+		if f.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		r, _ := f.Open()
+		sheet, _ := io.ReadAll(r)
+		expected := `<row><c><v>1</v></c><c t="inlineStr"><is><t>&#39;=HYPERLINK(&#34;http://x&#34;)</t></is></c><c><v>-1</v></c></row>`
+		if !strings.Contains(string(sheet), expected) {
+			t.Errorf("Expected the formulas to be escaped in the sheet, got %s", sheet)
+		}
+		return
+	}
+	t.Errorf("Expected the workbook to have a sheet")
+}
+
+func doUpload(app *gin.Engine, path, fileName, content string) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("file", fileName)
+	_, _ = part.Write([]byte(content))
+	_ = writer.Close()
+	req := httptest.NewRequest("POST", path, body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	return w
+}
+
+func TestImport_CSVDryRunDoesNotWrite(t *testing.T) {
+	_, app, db := newTestCtrl[Car](t)
+	csvFile := "Name,Year\ngolf,2001\npolo,new\n"
+
+	w := doUpload(app, "/cars/import?dry_run=true", "cars.csv", csvFile)
+	var report BulkReport
+	_ = json.Unmarshal(w.Body.Bytes(), &report)
+	if w.Code != http.StatusUnprocessableEntity || !report.DryRun || report.Succeeded != 1 || report.Failed != 1 {
+		t.Fatalf("Expected a dry run report with one failed row, got %d %s", w.Code, w.Body.String())
+	}
+	if report.Results[1].Errors["Year"] != "Year must be a whole number" {
+		t.Errorf("Expected the conversion error of the row, got %v", report.Results[1])
+	}
+	var count int64
+	db.Model(&Car{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected nothing to be written, got %d items", count)
+	}
+}
+
+func TestImport_CSVUpsertsByKey(t *testing.T) {
+	_, app, db := newTestCtrl[Car](t)
+	db.Create(&Car{Name: "golf", Year: 2001})
+
+	w := doUpload(app, "/cars/import", "cars.csv", "ID,CreatedAt,Name,Year\n1,2024-01-01T00:00:00Z,golf gti,2002\n,,polo,2003\n")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d %s", w.Code, w.Body.String())
+	}
+	var cars []Car
+	db.Order("id").Find(&cars)
+	if len(cars) != 2 || cars[0].Name != "golf gti" || cars[0].Year != 2002 || cars[1].Name != "polo" {
+		t.Errorf("Expected the first car to be updated and the second created, got %v", cars)
+	}
+	if w := doUpload(app, "/cars/import", "cars.csv", "Name,Color\ngolf,red\n"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown column, got %d", w.Code)
+	}
+}
+
+func TestImport_JSON(t *testing.T) {
+	_, app, db := newTestCtrl[Car](t)
+
+	w := doUpload(app, "/cars/import", "cars.ndjson", `{"Name":"golf"}`+"\n"+`{"Name":"polo"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d %s", w.Code, w.Body.String())
+	}
+	req := httptest.NewRequest("POST", "/cars/import", strings.NewReader(`[{"Name":"up"},{"Name":"fox","Year":"old"}]`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected the import to fail as a whole, got %d %s", w.Code, w.Body.String())
+	}
+	var count int64
+	db.Model(&Car{}).Count(&count)
+	if count != 2 {
+		t.Errorf("Expected only the first file to be imported, got %d items", count)
+	}
+}
+
+type Trip struct {
+	ID        uint
+	Name      string
+	Distance  int64
+	StartedAt time.Time
+	EndedAt   *time.Time
+	Rating    *float64
+	Done      bool
+}
+
+func TestImport_ExportedCSVRoundTrip(t *testing.T) {
+	_, app, db := newTestCtrl[Trip](t)
+	started := time.Date(2024, 5, 1, 8, 30, 15, 123456789, time.UTC)
+	ended, rating := started.Add(90*time.Minute), 4.5
+	trips := []Trip{
+		{Name: "=SUM(A1)", Distance: 1 << 40, StartedAt: started, EndedAt: &ended, Rating: &rating, Done: true},
+		{Name: "-5 notes", Distance: -3, StartedAt: started},
+		{Name: "'quoted", StartedAt: started},
+	}
+	db.Create(&trips)
+
+	w := doRequest(app, "GET", "/cars/export", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected the export, got %d %s", w.Code, w.Body.String())
+	}
+	db.Where("1 = 1").Delete(&Trip{})
+	req := httptest.NewRequest("POST", "/cars/import", strings.NewReader(w.Body.String()))
+	req.Header.Set("Content-Type", "text/csv")
+	imported := httptest.NewRecorder()
+	app.ServeHTTP(imported, req)
+	if imported.Code != http.StatusOK {
+		t.Fatalf("Expected the exported file to be imported, got %d %s\n%s", imported.Code, imported.Body.String(), w.Body.String())
+	}
+
+	var result []Trip
+	db.Order("id").Find(&result)
+	if len(result) != 3 {
+		t.Fatalf("Expected 3 trips, got %+v", result)
+	}
+	first, second := result[0], result[1]
+	if first.Name != "=SUM(A1)" || second.Name != "-5 notes" || result[2].Name != "'quoted" {
+		t.Errorf("Expected the names without the quote of the export, got %q %q %q", first.Name, second.Name, result[2].Name)
+	}
+	if first.Distance != 1<<40 || !first.StartedAt.Equal(started) || first.EndedAt == nil || !first.EndedAt.Equal(ended) ||
+		first.Rating == nil || *first.Rating != 4.5 || !first.Done {
+		t.Errorf("Expected the values of the first trip, got %+v", first)
+	}
+	if second.Distance != -3 || second.EndedAt != nil || second.Rating != nil || second.Done {
+		t.Errorf("Expected the values of the second trip, got %+v", second)
+	}
+}
+
+func TestImport_LimitsTheBody(t *testing.T) {
+	_, app, _ := newTestCtrl[Car](t)
+	ImportMaxBytes = 64
+	defer func() { ImportMaxBytes = 32 << 20 }()
+
+	req := httptest.NewRequest("POST", "/cars/import", strings.NewReader("Name,Year\n"+strings.Repeat("golf,2001\n", 20)))
+	req.Header.Set("Content-Type", "text/csv")
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413, got %d %s", w.Code, w.Body.String())
+	}
+	if w := doUpload(app, "/cars/import", "cars.csv", strings.Repeat("golf,2001\n", 20)); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for a large upload, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestImport_IsAuthorized(t *testing.T) {
+	ctrl, app, db := newTestCtrl[Car](t)
+	ctrl.Config.(*Config).WithUI(true)
+	ctrl.OnRouter(app.Group("/ui"))
+	ctrl.WithAuthorizer(&Policy[Car]{
+		AuthorizeFunc: func(c *gin.Context, action Action, item *Car) error {
+			if action == ActionCreate || action == ActionUpdate {
+				return errors.New("read only user")
+			}
+			return nil
+		},
+	})
+
+	if w := doRequest(app, "GET", "/ui/import", nil); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for the import form, got %d", w.Code)
+	}
+	if w := doUpload(app, "/cars/import", "cars.csv", "Name\ngolf\n"); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for the import, got %d %s", w.Code, w.Body.String())
+	}
+	var count int64
+	db.Model(&Car{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected nothing to be imported, got %d items", count)
+	}
+}
+
+func TestSearch_Like(t *testing.T) {
+	_, app, db := newTestCtrl[Car](t)
+	db.Create(&Car{Name: "Golf", Owner: "ann"})
+	db.Create(&Car{Name: "Polo", Owner: "bob"})
+	db.Create(&Car{Name: "100%", Owner: "ann"})
+	db.Create(&Car{Name: "Up!", Owner: "cid"})
+
+	search := func(q string) []string {
+		w := doRequest(app, "GET", "/cars/?"+url.Values{"q": {q}}.Encode(), nil)
+		var list listResponse[Car]
+		_ = json.Unmarshal(w.Body.Bytes(), &list)
+		names := []string{}
+		for _, car := range list.Items {
+			names = append(names, car.Name)
+		}
+		return names
+	}
+	if names := search("golf"); len(names) != 1 || names[0] != "Golf" {
+		t.Errorf("Expected a case insensitive match, got %v", names)
+	}
+	if names := search("ann"); len(names) != 2 {
+		t.Errorf("Expected the match on any string field, got %v", names)
+	}
+	if names := search("ann polo"); len(names) != 0 {
+		t.Errorf("Expected every term to match, got %v", names)
+	}
+	if names := search("%"); len(names) != 1 || names[0] != "100%" {
+		t.Errorf("Expected the wildcards to be escaped, got %v", names)
+	}
+	if names := search("!"); len(names) != 1 || names[0] != "Up!" {
+		t.Errorf("Expected the escape character to be escaped, got %v", names)
+	}
+}
+
+type Tag struct {
+	ID    uint
+	Label string `crud-search:""`
+	Uses  int    `crud-search:""`
+}
+
+func TestSearch_LikeSkipsTheFieldsThatAreNotStrings(t *testing.T) {
+	_, app, db := newTestCtrl[Tag](t)
+	db.Create(&Tag{Label: "go", Uses: 12})
+
+	w := doRequest(app, "GET", "/cars/?q=go", nil)
+	var list listResponse[Tag]
+	_ = json.Unmarshal(w.Body.Bytes(), &list)
+	if w.Code != http.StatusOK || len(list.Items) != 1 {
+		t.Errorf("Expected the match on the string field, got %d %s", w.Code, w.Body.String())
+	}
+	if w := doRequest(app, "GET", "/cars/?q=12", nil); w.Code != http.StatusOK || strings.Contains(w.Body.String(), `"Label"`) {
+		t.Errorf("Expected the int field not to be searched with LIKE, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+type Post struct {
+	ID    uint
+	Title string `crud-search:""`
+	Body  string
+}
+
+func TestSearch_TaggedFields(t *testing.T) {
+	_, app, db := newTestCtrl[Post](t)
+	db.Create(&Post{Title: "Go generics", Body: "crudex"})
+	db.Create(&Post{Title: "crudex", Body: "gin"})
+
+	w := doRequest(app, "GET", "/cars/?q=crudex", nil)
+	var list listResponse[Post]
+	_ = json.Unmarshal(w.Body.Bytes(), &list)
+	if len(list.Items) != 1 || list.Items[0].ID != 2 {
+		t.Errorf("Expected only the tagged fields to be searched, got %s", w.Body.String())
+	}
+}
+
+func TestSearch_FTS5(t *testing.T) {
+	ctrl, app, db := newTestCtrl[Post](t)
+	if err := db.Exec("CREATE VIRTUAL TABLE posts_fts USING fts5(title, content='posts', content_rowid='id')").Error; err != nil {
+		t.Skipf("FTS5 is not available(build with -tags sqlite_fts5): %s", err)
+	}
+	ctrl.Config.(*Config).WithSearcher(SearchFTS5(""))
+	db.Create(&Post{Title: "Go generics"})
+	db.Create(&Post{Title: "Generic \"crud\" controllers"})
+	db.Exec("INSERT INTO posts_fts(posts_fts) VALUES('rebuild')")
+
+	w := doRequest(app, "GET", "/cars/?"+url.Values{"q": {`"crud" generic`}}.Encode(), nil)
+	var list listResponse[Post]
+	_ = json.Unmarshal(w.Body.Bytes(), &list)
+	if len(list.Items) != 1 || list.Items[0].ID != 2 {
+		t.Errorf("Expected the full text match, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+type Bike struct {
+	ID       uint
+	Name     string
+	Price    float64
+	Electric bool
+	BuiltAt  time.Time
+}
+
+func TestFilter_ByFieldType(t *testing.T) {
+	_, app, db := newTestCtrl[Bike](t)
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	db.Create(&Bike{Name: "City", Price: 300, Electric: false, BuiltAt: day})
+	db.Create(&Bike{Name: "E-City", Price: 1500, Electric: true, BuiltAt: day.Add(20 * time.Hour)})
+	db.Create(&Bike{Name: "Mountain", Price: 900, Electric: false, BuiltAt: day.AddDate(0, 0, 1)})
+
+	filter := func(query url.Values) []string {
+		w := doRequest(app, "GET", "/cars/?"+query.Encode(), nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var list listResponse[Bike]
+		_ = json.Unmarshal(w.Body.Bytes(), &list)
+		names := []string{}
+		for _, bike := range list.Items {
+			names = append(names, bike.Name)
+		}
+		return names
+	}
+	if names := filter(url.Values{"f.Name": {"city"}}); len(names) != 2 {
+		t.Errorf("Expected the text fields to match the contained value, got %v", names)
+	}
+	if names := filter(url.Values{"f.Price.from": {"500"}, "f.Price.to": {"1000"}}); len(names) != 1 || names[0] != "Mountain" {
+		t.Errorf("Expected the numeric range, got %v", names)
+	}
+	if names := filter(url.Values{"f.Electric": {"false"}}); len(names) != 2 {
+		t.Errorf("Expected the boolean filter, got %v", names)
+	}
+	if names := filter(url.Values{"f.Electric": {""}}); len(names) != 3 {
+		t.Errorf("Expected the empty filters to be ignored, got %v", names)
+	}
+	if names := filter(url.Values{"f.BuiltAt.to": {"2024-05-01"}}); len(names) != 2 {
+		t.Errorf("Expected the date range to include the whole day, got %v", names)
+	}
+	if w := doRequest(app, "GET", "/cars/?f.Color=red", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown field, got %d", w.Code)
+	}
+	if w := doRequest(app, "GET", "/cars/?f.Price.from=cheap", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid value, got %d", w.Code)
+	}
+}
+
+func TestList_PagerAndSortInTheTemplate(t *testing.T) {
+	ctrl, app, db := newTestCtrl[Car](t)
+	ctrl.Config.(*Config).WithUI(true)
+	app.HTMLRender = ginrender.HTMLProduction{Template: template.Must(template.New("car-list.html").Parse(
+		`{{range .CarList}}{{.Name}};{{end}}{{with .Page}}{{if .HasPrev}}prev={{.Prev}};{{end}}{{if .HasNext}}next={{.Next}};{{end}}{{end}}` +
+			`{{call .Sort "Name"}}|{{call .SortOrder "Name"}}|{{call .SortOrder "Year"}}`))}
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		db.Create(&Car{Name: name})
+	}
+
+	w := doAs(app, "", "GET", "/cars/?$top=2&$skip=2&$orderby=name%20asc", "Accept", "text/html", "HX-Request", "true")
+	if w.Body.String() != "c;d;prev=0;next=4;name desc|asc|" {
+		t.Errorf("Unexpected page, got %s", w.Body.String())
+	}
+	w = doAs(app, "", "GET", "/cars/?$top=2&$skip=4&$orderby=name%20desc", "Accept", "text/html", "HX-Request", "true")
+	if w.Body.String() != "a;prev=2;name asc|desc|" {
+		t.Errorf("Unexpected last page, got %s", w.Body.String())
+	}
+}
+
+func TestList_PageEnvelope(t *testing.T) {
+	ctrl, app, db := newTestCtrl[Car](t)
+	ctrl.Config.(*Config).WithPageSize(2, 3)
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		db.Create(&Car{Name: name, Year: 2000})
+	}
+	list := func(path string) ([]Car, Page) {
+		w := doRequest(app, "GET", path, nil)
+		var res listResponse[Car]
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatalf("Invalid response %d: %s", w.Code, w.Body.String())
+		}
+		return res.Items, res.Page
+	}
+
+	items, page := list("/cars/")
+	if len(items) != 2 || page.Size != 2 || page.HasPrev || !page.HasNext || page.Count != nil {
+		t.Errorf("Expected the default page size, got %d items and %+v", len(items), page)
+	}
+	next, _ := url.Parse(page.NextLink)
+	if next.Path != "/cars/" || next.Query().Get("$skip") != "2" || next.Query().Get("$top") != "2" {
+		t.Errorf("Unexpected next link %s", page.NextLink)
+	}
+
+	items, page = list("/cars/?$top=100&$skip=3&$count=true&$filter=Year%20eq%202000")
+	if len(items) != 2 || page.Size != 3 || page.HasNext || page.Count == nil || *page.Count != 5 {
+		t.Errorf("Expected the page size to be limited and the total count, got %d items and %+v", len(items), page)
+	}
+	prev, _ := url.Parse(page.PrevLink)
+	if prev.Query().Get("$skip") != "0" || prev.Query().Get("$filter") != "Year eq 2000" || page.NextLink != "" {
+		t.Errorf("Unexpected links %s %s", page.PrevLink, page.NextLink)
+	}
+
+	w := doRequest(app, "GET", "/cars/?$count=true", nil)
+	var res listResponse[Car]
+	_ = json.Unmarshal(w.Body.Bytes(), &res)
+	if res.Count == nil || *res.Count != 5 || res.Links.Next != res.Page.NextLink || res.Links.Prev != "" {
+		t.Errorf("Expected the count and the links in the envelope, got %s", w.Body.String())
+	}
+	var keys map[string]json.RawMessage
+	_ = json.Unmarshal(w.Body.Bytes(), &keys)
+	if len(keys) != 4 || keys["items"] == nil || keys["count"] == nil || keys["links"] == nil || keys["page"] == nil {
+		t.Errorf("Expected the fixed keys of the envelope, got %s", w.Body.String())
+	}
+}
+
+func TestList_TopZeroReturnsAnEmptyPage(t *testing.T) {
+	_, app, db := newTestCtrl[Car](t)
+	db.Create(&Car{Name: "golf"})
+	db.Create(&Car{Name: "polo"})
+
+	w := doRequest(app, "GET", "/cars/?$top=0&$count=true", nil)
+	var res listResponse[Car]
+	_ = json.Unmarshal(w.Body.Bytes(), &res)
+	if w.Code != http.StatusOK || res.Items == nil || len(res.Items) != 0 || res.Count == nil || *res.Count != 2 || res.Links.Next != "" {
+		t.Errorf("Expected an empty page with the count, got %d %s", w.Code, w.Body.String())
+	}
+	res = listResponse[Car]{}
+	w = doRequest(app, "GET", "/cars/", nil)
+	_ = json.Unmarshal(w.Body.Bytes(), &res)
+	if len(res.Items) != 2 {
+		t.Errorf("Expected the default page size without $top, got %s", w.Body.String())
+	}
+}
+
+func TestList_CursorPaging(t *testing.T) {
+	ctrl, app, db := newTestCtrl[Car](t)
+	ctrl.WithCursorPaging(true)
+	for i, year := range []int{2001, 2003, 2003, 2002, 2003, 1999} {
+		db.Create(&Car{Name: fmt.Sprintf("car%d", i+1), Year: year})
+	}
+	list := func(path string) ([]Car, Page) {
+		w := doRequest(app, "GET", path, nil)
+		var res listResponse[Car]
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("Invalid response %d: %s", w.Code, w.Body.String())
+		}
+		return res.Items, res.Page
+	}
+
+	names := []string{}
+	path := "/cars/?$top=2&$orderby=Year%20desc&$filter=Year%20gt%202000"
+	for pages := 0; path != ""; pages++ {
+		if pages > 3 {
+			t.Fatalf("Expected the paging to end, got %v", names)
+		}
+		items, page := list(path)
+		if !page.Keyset || page.HasPrev {
+			t.Errorf("Expected a keyset page, got %+v", page)
+		}
+		for _, car := range items {
+			names = append(names, car.Name)
+		}
+		path = page.NextLink
+	}
+	if strings.Join(names, ",") != "car2,car3,car5,car4,car1" {
+		t.Errorf("Expected the items in order without duplicates, got %v", names)
+	}
+
+	_, page := list("/cars/?$top=2&$orderby=Year%20desc")
+	if w := doRequest(app, "GET", "/cars/?$top=2&$orderby=Name&$cursor="+page.NextCursor, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a cursor of another order, got %d", w.Code)
+	}
+	if w := doRequest(app, "GET", "/cars/?$cursor=invalid", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid cursor, got %d", w.Code)
+	}
+}
+
+func TestChangeStream_SendsTheChangesOfTheItems(t *testing.T) {
+	ctrl, app, _ := newTestCtrl[Car](t)
+	ctrl.WithChangeStream()
+	srv := httptest.NewServer(app)
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+"/cars/events?id=2", nil)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Error opening the stream: %s", err)
+	}
+	defer res.Body.Close()
+	if res.Header.Get("Content-Type") != "text/event-stream" {
+		t.Errorf("Expected an event stream, got %s", res.Header.Get("Content-Type"))
+	}
+
+	doRequest(app, "PUT", "/cars/new", url.Values{"Name": {"golf"}})
+	doRequest(app, "PUT", "/cars/new", url.Values{"Name": {"polo"}})
+	doRequest(app, "POST", "/cars/2", url.Values{"Name": {"polo gti"}})
+	doRequest(app, "DELETE", "/cars/2", nil)
+
+	events := []string{}
+	scanner := bufio.NewScanner(res.Body)
+	for len(events) < 3 && scanner.Scan() {
+		if data, ok := strings.CutPrefix(scanner.Text(), "data:"); ok {
+			var event struct {
+				ChangeEvent
+				Item Car `json:"item"`
+			}
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				t.Fatalf("Invalid event %s", data)
+			}
+			events = append(events, fmt.Sprintf("%s %s %s", event.Event, event.Key, event.Item.Name))
+		}
+	}
+	if strings.Join(events, ";") != "created 2 polo;updated 2 polo gti;deleted 2 polo gti" {
+		t.Errorf("Expected the events of the item 2, got %v", events)
+	}
+}
+
+type Invoice struct {
+	ID       uint
+	TenantID uint
+	Number   string
+}
+
+func TestTenant_ScopesTheQueries(t *testing.T) {
+	ctrl, app, db := newTestCtrl[Invoice](t)
+	ctrl.Config.(*Config).WithTenantResolver(TenantFromHeader("X-Tenant"))
+	db.Create(&Invoice{TenantID: 1, Number: "A-1"})
+	db.Create(&Invoice{TenantID: 2, Number: "B-1"})
+
+	w := doAs(app, "", "GET", "/cars/", "X-Tenant", "1")
+	var list listResponse[Invoice]
+	_ = json.Unmarshal(w.Body.Bytes(), &list)
+	if len(list.Items) != 1 || list.Items[0].Number != "A-1" {
+		t.Errorf("Expected only the invoices of the tenant, got %s", w.Body.String())
+	}
+	if w := doAs(app, "", "GET", "/cars/2", "X-Tenant", "1"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an invoice of another tenant, got %d", w.Code)
+	}
+	if w := doAs(app, "", "DELETE", "/cars/2", "X-Tenant", "1"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 when deleting an invoice of another tenant, got %d", w.Code)
+	}
+	if w := doAs(app, "", "GET", "/cars/"); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 without a tenant, got %d", w.Code)
+	}
+}
+
+func TestTenant_IsStampedOnSave(t *testing.T) {
+	ctrl, app, db := newTestCtrl[Invoice](t)
+	ctrl.Config.(*Config).WithTenantResolver(TenantFromHeader("X-Tenant"))
+	db.Create(&Invoice{TenantID: 2, Number: "B-1"})
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Tenant", "1")
+		app.ServeHTTP(w, req)
+		return w
+	}
+	if w := send("PUT", "/cars/new", `{"TenantID":2,"Number":"A-1"}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var invoice Invoice
+	db.Where("number = ?", "A-1").First(&invoice)
+	if invoice.TenantID != 1 {
+		t.Errorf("Expected the tenant of the request to be stamped, got %d", invoice.TenantID)
+	}
+	if w := send("PUT", "/cars/new", `{"ID":1,"Number":"hijacked"}`); w.Code == http.StatusOK {
+		t.Errorf("Expected the invoice of another tenant not to be overwritten")
+	}
+	if w := send("POST", "/cars/1", `{"Number":"hijacked"}`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 when updating an invoice of another tenant, got %d", w.Code)
+	}
+	var other Invoice
+	db.First(&other, 1)
+	if other.Number != "B-1" || other.TenantID != 2 {
+		t.Errorf("Expected the invoice of the other tenant to be unchanged, got %+v", other)
+	}
+}
+
+func newAuditedCtrl(t *testing.T) (*CrudCtrl[Car], *gin.Engine, *gorm.DB) {
+	ctrl, app, db := newTestCtrl[Car](t)
+	if err := db.AutoMigrate(new(AuditEntry)); err != nil {
+		t.Fatalf("Error migrating the audit log: %s", err)
+	}
+	ctrl.Config.(*Config).
+		WithAuditSink(NewGormAuditSink(db)).
+		WithActorResolver(func(c *gin.Context) string { return c.GetHeader("X-User") })
+	return ctrl, app, db
+}
+
+func TestAudit_RecordsTheChanges(t *testing.T) {
+	_, app, db := newAuditedCtrl(t)
+
+	send := func(method, path, body string) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User", "ann")
+		app.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200 for %s %s, got %d: %s", method, path, w.Code, w.Body.String())
+		}
+	}
+	send("PUT", "/cars/new", `{"Name":"golf","Year":2001}`)
+	send("PATCH", "/cars/1", `{"Year":2002}`)
+	send("DELETE", "/cars/1", ``)
+
+	var entries []AuditEntry
+	db.Order("id").Find(&entries)
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 audit entries, got %+v", entries)
+	}
+	created, updated, deleted := entries[0], entries[1], entries[2]
+	if created.Action != "create" || created.Actor != "ann" || created.Model != "Car" || created.RecordID != "1" {
+		t.Errorf("Unexpected create entry %+v", created)
+	}
+	if created.Changes["Name"].After != "golf" || created.Changes["Name"].Before != nil {
+		t.Errorf("Expected the created values, got %+v", created.Changes)
+	}
+	if len(updated.Changes) != 1 || updated.Changes["Year"].Before != float64(2001) || updated.Changes["Year"].After != float64(2002) {
+		t.Errorf("Expected only the changed field, got %+v", updated.Changes)
+	}
+	if deleted.Action != "delete" || deleted.Changes["Name"].Before != "golf" {
+		t.Errorf("Unexpected delete entry %+v", deleted)
+	}
+}
+
+func TestAudit_History(t *testing.T) {
+	_, app, _ := newAuditedCtrl(t)
+	doRequest(app, "PUT", "/cars/new", url.Values{"Name": {"golf"}})
+	doRequest(app, "POST", "/cars/1", url.Values{"Name": {"polo"}})
+
+	w := doRequest(app, "GET", "/cars/1/history", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var history map[string][]AuditEntry
+	_ = json.Unmarshal(w.Body.Bytes(), &history)
+	if len(history["AuditEntryList"]) != 2 || history["AuditEntryList"][0].Action != "update" {
+		t.Errorf("Expected the entries with the newest first, got %s", w.Body.String())
+	}
+}
+
+func TestAudit_CtrlIsReadOnly(t *testing.T) {
+	_, app, db := newAuditedCtrl(t)
+	doRequest(app, "PUT", "/cars/new", url.Values{"Name": {"golf"}})
+	NewAuditCtrl(db, app.Group("/audit"), NewConfig().WithAutoScaffold(false).WithUI(false))
+
+	w := doRequest(app, "GET", "/audit/", nil)
+	var list listResponse[AuditEntry]
+	_ = json.Unmarshal(w.Body.Bytes(), &list)
+	if len(list.Items) != 1 {
+		t.Errorf("Expected the audit entries to be listed, got %s", w.Body.String())
+	}
+	if w := doRequest(app, "DELETE", "/audit/1", nil); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405, got %d", w.Code)
+	}
+	if w := doRequest(app, "PUT", "/audit/new", url.Values{"Model": {"Car"}}); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405, got %d", w.Code)
+	}
+}
+
+func TestAudit_HistoryOfTheTrashedItems(t *testing.T) {
+	_, app, _ := newAuditedCtrl(t)
+	doRequest(app, "PUT", "/cars/new", url.Values{"Name": {"golf"}})
+	doRequest(app, "DELETE", "/cars/1", nil)
+
+	w := doRequest(app, "GET", "/cars/1/history", nil)
+	var history map[string][]AuditEntry
+	_ = json.Unmarshal(w.Body.Bytes(), &history)
+	if w.Code != http.StatusOK || len(history["AuditEntryList"]) != 2 || history["AuditEntryList"][0].Action != "delete" {
+		t.Errorf("Expected the history of the deleted item, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestAudit_FailsWithoutTheTenant(t *testing.T) {
+	ctrl, app, db := newAuditedCtrl(t)
+	ctrl.Config.(*Config).WithTenantResolver(TenantFromHeader("X-Tenant"))
+
+	if w := doRequest(app, "PUT", "/cars/new", url.Values{"Name": {"golf"}}); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 when the audit entry has no tenant, got %d %s", w.Code, w.Body.String())
+	}
+	if w := doBody(app, "PUT", "/cars/bulk", "application/json", strings.NewReader(`[{"Name":"polo"}]`)); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for the bulk write, got %d %s", w.Code, w.Body.String())
+	}
+	var cars, entries int64
+	db.Model(&Car{}).Count(&cars)
+	db.Model(&AuditEntry{}).Count(&entries)
+	if cars != 0 || entries != 0 {
+		t.Errorf("Expected nothing to be written, got %d cars and %d entries", cars, entries)
+	}
+	db.Create(&Car{Name: "golf"})
+	if w := doRequest(app, "GET", "/cars/1/history", nil); w.Code != http.StatusForbidden {
+		t.Errorf("Expected the history to fail without the tenant, got %d", w.Code)
+	}
+}
+
+func TestAudit_ScopedByTenant(t *testing.T) {
+	_, app, db := newTestCtrl[Invoice](t)
+	if err := db.AutoMigrate(new(AuditEntry)); err != nil {
+		t.Fatalf("Error migrating the audit log: %s", err)
+	}
+	conf := NewConfig().WithAutoScaffold(false).WithUI(false).
+		WithTenantResolver(TenantFromHeader("X-Tenant")).
+		WithAuditSink(NewGormAuditSink(db))
+	NewWithOptions[Invoice](db, app.Group("/invoices"), conf)
+	NewAuditCtrl(db, app.Group("/audit"), conf)
+
+	send := func(tenant, method, path string, form url.Values) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("X-Tenant", tenant)
+		app.ServeHTTP(w, req)
+		return w
+	}
+	send("1", "PUT", "/invoices/new", url.Values{"Number": {"A-1"}})
+	send("2", "PUT", "/invoices/new", url.Values{"Number": {"B-1"}})
+
+	var entries []AuditEntry
+	db.Order("id").Find(&entries)
+	if len(entries) != 2 || entries[0].TenantID != "1" || entries[1].TenantID != "2" {
+		t.Fatalf("Expected the entries to be stamped with the tenant, got %+v", entries)
+	}
+	w := send("1", "GET", "/audit/", nil)
+	var list listResponse[AuditEntry]
+	_ = json.Unmarshal(w.Body.Bytes(), &list)
+	if len(list.Items) != 1 || list.Items[0].RecordID != "1" {
+		t.Errorf("Expected only the entries of the tenant, got %s", w.Body.String())
+	}
+	if w := send("1", "GET", "/audit/2", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for the entry of another tenant, got %d", w.Code)
+	}
+	// the history of a record with the same key in another tenant
+	db.Create(&AuditEntry{TenantID: "2", Model: "Invoice", RecordID: "1", Action: "update"})
+	w = send("1", "GET", "/invoices/1/history", nil)
+	var history map[string][]AuditEntry
+	_ = json.Unmarshal(w.Body.Bytes(), &history)
+	if len(history["AuditEntryList"]) != 1 || history["AuditEntryList"][0].TenantID != "1" {
+		t.Errorf("Expected only the history of the tenant, got %s", w.Body.String())
+	}
+}
+
+type Driver struct {
+	ID   uint
+	Name string `validate:"required"`
+	Age  int    `validate:"gte=18"`
+}
+
+func (self *Driver) Validate() error {
+	if self.Name == "admin" {
+		return NewValidationError().Add("Name", "Name is reserved")
+	}
+	return nil
+}
+
+func TestValidation_JsonErrorsByField(t *testing.T) {
+	_, app, db := newTestCtrl[Driver](t)
+
+	w := doBody(app, "PUT", "/cars/new", "application/json", strings.NewReader(`{"Age":10}`))
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected 422, got %d: %s", w.Code, w.Body.String())
+	}
+	var problem Problem
+	_ = json.Unmarshal(w.Body.Bytes(), &problem)
+	if problem.Errors["Name"] != "Name is required" || problem.Errors["Age"] != "Age must be at least 18" {
+		t.Errorf("Expected the errors by field, got %s", w.Body.String())
+	}
+
+	w = doBody(app, "PUT", "/cars/new", "application/json", strings.NewReader(`{"Name":"ann","Age":"old"}`))
+	_ = json.Unmarshal(w.Body.Bytes(), &problem)
+	if w.Code != http.StatusUnprocessableEntity || problem.Errors["Age"] == "" {
+		t.Errorf("Expected the type error of the field, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = doBody(app, "PUT", "/cars/new", "application/json", strings.NewReader(`{"Name":"admin","Age":30}`))
+	_ = json.Unmarshal(w.Body.Bytes(), &problem)
+	if w.Code != http.StatusUnprocessableEntity || problem.Errors["Name"] != "Name is reserved" {
+		t.Errorf("Expected the error of the Validate method, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var count int64
+	db.Model(&Driver{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected the invalid items not to be saved, got %d items", count)
+	}
+}
+
+func TestValidation_FormIsRenderedWithTheErrors(t *testing.T) {
+	ctrl, app, _ := newTestCtrl[Driver](t)
+	ctrl.Config.(*Config).WithUI(true)
+	app.HTMLRender = ginrender.HTMLProduction{Template: template.Must(template.New("driver-form.html").Parse(
+		`{{.IsNew}}|{{.Errors.Age}}|{{$.Values.Get "Name"}}|{{$.Values.Get "Age"}}`))}
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("PUT", "/cars/new", strings.NewReader(url.Values{"Name": {"ann"}, "Age": {"ten"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "text/html")
+	req.Header.Set("HX-Request", "true")
+	app.ServeHTTP(w, req)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected 422, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Body.String() != "true|Age must be a whole number|ann|ten" {
+		t.Errorf("Expected the form with the errors and the submitted values, got %s", w.Body.String())
+	}
+}
+
+// customConfig implements only IConfig, like the configurations that were written before IConfigOptions
+type customConfig struct {
+	IConfig
+}
+
+func TestConfig_WithoutOptions(t *testing.T) {
+	_, app, db := newTestCtrl[Invoice](t)
+	NewWithOptions[Invoice](db, app.Group("/invoices"), customConfig{NewConfig().WithAutoScaffold(false).WithUI(false)})
+	db.Create(&Invoice{TenantID: 1, Number: "A-1"})
+	db.Create(&Invoice{TenantID: 2, Number: "B-1"})
+
+	w := doRequest(app, "GET", "/invoices/?q=A", nil)
+	var list listResponse[Invoice]
+	_ = json.Unmarshal(w.Body.Bytes(), &list)
+	if w.Code != http.StatusOK || len(list.Items) != 1 || list.Page.Size != 50 {
+		t.Errorf("Expected the default options, got %d %s", w.Code, w.Body.String())
+	}
+	if w := doRequest(app, "DELETE", "/invoices/2", nil); w.Code != http.StatusOK {
+		t.Errorf("Expected the invoice to be deleted, got %d %s", w.Code, w.Body.String())
+	}
+}
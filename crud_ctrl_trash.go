@@ -0,0 +1,145 @@
+package crudex
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// SoftDeleteField returns the soft delete field(gorm.DeletedAt) of the model, or nil if the model is always hard deleted
+func (self *CrudCtrl[T]) SoftDeleteField() *schema.Field {
+	return softDeleteField(self.Schema())
+}
+
+// HasTrash returns true if the model is soft deleted, so the controller has the trash, restore and purge routes
+func (self *CrudCtrl[T]) HasTrash() bool {
+	return self.SoftDeleteField() != nil
+}
+
+func softDeleteField(sch *schema.Schema) *schema.Field {
+	for _, field := range sch.Fields {
+		if field.FieldType == reflect.TypeOf(gorm.DeletedAt{}) {
+			return field
+		}
+	}
+	return nil
+}
+
+// Trash is a handler that lists the soft deleted items of the model
+// it is a GET request
+// !Requires the template to be named as modelName-trash.html where the modelName is lowercased model name
+func (self *CrudCtrl[T]) Trash(c *gin.Context) {
+	field := self.SoftDeleteField()
+	if field == nil {
+		self.fail(c, http.StatusNotFound, NewHttpError(http.StatusNotFound, fmt.Sprintf("%s has no trash", self.ModelName)))
+		return
+	}
+	if err := self.authorize(c, ActionList, nil); err != nil {
+		self.fail(c, http.StatusForbidden, err)
+		return
+	}
+	var items []T
+	if err := self.scope(c, self.Db).Unscoped().Where(fmt.Sprintf("%s IS NOT NULL", field.DBName)).Find(&items).Error; err != nil {
+		self.fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	self.Respond(c,
+		listData(c, self.Config, self.ModelName, &items, self.Router.BasePath(), nil),
+		fmt.Sprintf("%s-trash.html", strings.ToLower(self.ModelName)))
+}
+
+// Restore is a handler that restores a soft deleted item of the model
+// it is a POST request
+// It redirects to the details page of the restored item
+func (self *CrudCtrl[T]) Restore(c *gin.Context) {
+	field := self.SoftDeleteField()
+	if field == nil {
+		self.fail(c, http.StatusNotFound, NewHttpError(http.StatusNotFound, fmt.Sprintf("%s has no trash", self.ModelName)))
+		return
+	}
+	key, err := self.parseKey(c)
+	if err != nil {
+		self.fail(c, http.StatusBadRequest, err)
+		return
+	}
+	var item T
+	if err := self.findDeleted(c, key, &item); err != nil {
+		self.fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	if err := self.authorize(c, ActionUpdate, &item); err != nil {
+		self.fail(c, http.StatusForbidden, err)
+		return
+	}
+	deleted := item
+	change, err := self.commit(c, func(tx *gorm.DB) (*Change[T], error) {
+		if err := tx.Unscoped().Model(&item).Update(field.DBName, nil).Error; err != nil {
+			return nil, err
+		}
+		return &Change[T]{Action: ActionRestore, Key: self.Keys.Format(&item), Before: &deleted, After: &item}, nil
+	})
+	if err != nil {
+		self.fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	self.notify(c, *change)
+	c.Header("HX-Redirect", fmt.Sprintf("%s/%s", self.BasePath(), self.Keys.Format(&item)))
+	c.String(http.StatusOK, "Restored")
+	c.Abort()
+}
+
+// Purge is a handler that permanently deletes an item of the model, regardless if it is soft deleted or not
+// it is a DELETE request
+// It redirects to the trash page
+func (self *CrudCtrl[T]) Purge(c *gin.Context) {
+	key, err := self.parseKey(c)
+	if err != nil {
+		self.fail(c, http.StatusBadRequest, err)
+		return
+	}
+	var item T
+	if err := self.findIn(self.scope(c, self.Db).Unscoped(), key, &item); err != nil {
+		self.fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	if err := self.authorize(c, ActionDelete, &item); err != nil {
+		self.fail(c, http.StatusForbidden, err)
+		return
+	}
+	if err := self.Hooks.run(c, self.Hooks.BeforeDelete, &item); err != nil {
+		self.fail(c, http.StatusBadRequest, err)
+		return
+	}
+	change, err := self.commit(c, func(tx *gorm.DB) (*Change[T], error) {
+		if err := tx.Unscoped().Delete(&item).Error; err != nil {
+			return nil, err
+		}
+		return &Change[T]{Action: ActionPurge, Key: self.Keys.Format(&item), Before: &item}, nil
+	})
+	if err != nil {
+		self.fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	if err := self.Hooks.run(c, self.Hooks.AfterDelete, &item); err != nil {
+		self.fail(c, http.StatusInternalServerError, err)
+		return
+	}
+	self.notify(c, *change)
+	c.Header("HX-Redirect", fmt.Sprintf("%s/trash", self.BasePath()))
+	c.String(http.StatusOK, "Purged")
+	c.Abort()
+}
+
+// findDeleted loads the soft deleted item with the given key
+//
+// It returns an *HttpError with status 404 if there is no such item in the trash
+func (self *CrudCtrl[T]) findDeleted(c *gin.Context, key Key, out *T) error {
+	field := self.SoftDeleteField()
+	db := self.scope(c, self.Db).Unscoped().Where(fmt.Sprintf("%s IS NOT NULL", field.DBName))
+	return self.findIn(db, key, out)
+}
@@ -0,0 +1,89 @@
+package crudex
+
+import (
+	"errors"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// MIMEProblemJSON is the content type of the RFC 7807 problem details documents
+const MIMEProblemJSON = "application/problem+json"
+
+// HttpError is an error that carries the http status that should be sent to the client
+//
+// It can be returned from the controller hooks in order to veto an operation with a specific status,
+// any other error is reported with the default status of the handler
+type HttpError struct {
+	Status  int
+	Message string
+	Err     error
+}
+
+// NewHttpError creates a new HttpError with the given status and message
+func NewHttpError(status int, message string) *HttpError {
+	return &HttpError{Status: status, Message: message}
+}
+
+// WrapHttpError creates a new HttpError with the given status that wraps the provided error
+func WrapHttpError(status int, err error) *HttpError {
+	return &HttpError{Status: status, Message: err.Error(), Err: err}
+}
+
+func (self *HttpError) Error() string {
+	if self.Message == "" {
+		return http.StatusText(self.Status)
+	}
+	return self.Message
+}
+
+func (self *HttpError) Unwrap() error {
+	return self.Err
+}
+
+// Problem is the RFC 7807 problem details representation of an error that is sent to the API clients
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Errors maps the invalid fields to their error message, it is set for the validation errors
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// NewProblem creates the problem details for the error
+func NewProblem(status int, err error) *Problem {
+	problem := &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: err.Error(),
+	}
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) && len(validationErr.Errors) > 0 {
+		problem.Errors = validationErr.Errors
+	}
+	return problem
+}
+
+// ErrorStatus returns the http status that matches the error
+//
+//   - *HttpError carries its own status
+//   - *ValidationError is mapped to 422
+//   - gorm.ErrRecordNotFound is mapped to 404
+//   - any other error is mapped to the provided default status
+func ErrorStatus(err error, defaultStatus int) int {
+	var httpErr *HttpError
+	var validationErr *ValidationError
+	switch {
+	case errors.As(err, &httpErr):
+		return httpErr.Status
+	case errors.As(err, &validationErr):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	default:
+		return defaultStatus
+	}
+}
@@ -0,0 +1,147 @@
+package crudex
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ModelEvent is a change of an item of the model T that is published on the EventBus
+type ModelEvent[T IEntity] struct {
+	// Event is the name of the event, created, updated or deleted
+	Event string
+	// Action is the action of the controller that made the change, e.g. ActionRestore for a created event
+	Action Action
+	// Key is the route key of the changed item
+	Key string
+	// Item is the item after the change, or before the change for the deleted items
+	Item *T
+	// Before is the item before the change, it is nil for the created items
+	Before *T
+}
+
+// EventHandler handles the events of the model T, c is the context of the request that made the change
+//
+// The change is already committed, so the errors of the handlers are only logged and reported with `c.Error`
+type EventHandler[T IEntity] func(c *gin.Context, event ModelEvent[T]) error
+
+// subscription is a handler of the events of a model
+type subscription struct {
+	id      uint64
+	events  []string
+	async   bool
+	handler func(c *gin.Context, event interface{}) error
+}
+
+// EventBus dispatches the changes of the items that are saved through the controllers to the subscribers in the application,
+// e.g. search indexers, caches or notifications
+//
+// The subscribers are registered for a model with `Subscribe` or `SubscribeAsync`
+type EventBus struct {
+	mu          sync.RWMutex
+	lastID      uint64
+	subscribers map[reflect.Type][]subscription
+	pending     sync.WaitGroup
+}
+
+// NewEventBus creates an event bus without subscribers
+func NewEventBus() *EventBus {
+	return &EventBus{subscribers: map[reflect.Type][]subscription{}}
+}
+
+// Subscribe registers a handler of the events of the model T that is invoked before the response is sent
+//
+// The handler receives only the listed events, or every event if none is listed. The returned function removes the subscription
+func Subscribe[T IEntity](bus *EventBus, handler EventHandler[T], events ...string) func() {
+	return subscribe(bus, handler, false, events)
+}
+
+// SubscribeAsync registers a handler of the events of the model T that is invoked in its own goroutine
+//
+// The handler receives a copy of the request context that can be used after the response is sent, see `gin.Context.Copy`.
+// Use `bus.Wait()` to wait for the running handlers, e.g. on shutdown
+func SubscribeAsync[T IEntity](bus *EventBus, handler EventHandler[T], events ...string) func() {
+	return subscribe(bus, handler, true, events)
+}
+
+func subscribe[T IEntity](bus *EventBus, handler EventHandler[T], async bool, events []string) func() {
+	typ := reflect.TypeFor[T]()
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	bus.lastID++
+	sub := subscription{
+		id:     bus.lastID,
+		events: events,
+		async:  async,
+		handler: func(c *gin.Context, event interface{}) error {
+			return handler(c, event.(ModelEvent[T]))
+		},
+	}
+	bus.subscribers[typ] = append(bus.subscribers[typ], sub)
+	return func() {
+		bus.mu.Lock()
+		defer bus.mu.Unlock()
+		bus.subscribers[typ] = slices.DeleteFunc(bus.subscribers[typ], func(s subscription) bool { return s.id == sub.id })
+	}
+}
+
+// Publish sends the event to the subscribers of the model T
+//
+// The synchronous handlers are invoked in the order of their subscription, the asynchronous ones are started in their own goroutines
+func Publish[T IEntity](bus *EventBus, c *gin.Context, event ModelEvent[T]) {
+	bus.mu.RLock()
+	subs := slices.Clone(bus.subscribers[reflect.TypeFor[T]()])
+	bus.mu.RUnlock()
+	for _, sub := range subs {
+		if len(sub.events) > 0 && !slices.Contains(sub.events, event.Event) {
+			continue
+		}
+		if !sub.async {
+			bus.invoke(c, sub, event)
+			continue
+		}
+		bus.pending.Add(1)
+		go func(c *gin.Context, sub subscription) {
+			defer bus.pending.Done()
+			bus.invoke(c, sub, event)
+		}(c.Copy(), sub)
+	}
+}
+
+// Wait waits for the asynchronous handlers that are running
+func (self *EventBus) Wait() {
+	self.pending.Wait()
+}
+
+// invoke runs the handler, its errors and panics are logged so they do not affect the other subscribers
+func (self *EventBus) invoke(c *gin.Context, sub subscription, event interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("The event handler panicked", slog.String("type", fmt.Sprintf("%T", event)), slog.Any("panic", r))
+		}
+	}()
+	if err := sub.handler(c, event); err != nil {
+		_ = c.Error(err)
+		slog.Error("The event handler failed", slog.String("type", fmt.Sprintf("%T", event)), slog.Any("error", err))
+	}
+}
+
+// publish sends the change to the subscribers of the event bus of the configuration
+func (self *CrudCtrl[T]) publish(c *gin.Context, change Change[T]) {
+	bus := self.options().EventBus()
+	if bus == nil {
+		return
+	}
+	event := change.Event(self.ModelName)
+	Publish(bus, c, ModelEvent[T]{
+		Event:  event.Event,
+		Action: change.Action,
+		Key:    change.Key,
+		Item:   event.Item.(*T),
+		Before: change.Before,
+	})
+}
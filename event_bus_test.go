@@ -0,0 +1,67 @@
+package crudex
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestEventBus_SyncSubscribersReceiveTheChanges(t *testing.T) {
+	ctrl, app, _ := newTestCtrl[Car](t)
+	events := []string{}
+	Subscribe(ctrl.Config.(*Config).EventBus(), func(c *gin.Context, event ModelEvent[Car]) error {
+		events = append(events, fmt.Sprintf("%s %s %s by %s", event.Event, event.Key, event.Item.Name, c.GetHeader("X-User")))
+		return nil
+	}, EventCreated, EventDeleted)
+	Subscribe(ctrl.Config.(*Config).EventBus(), func(c *gin.Context, event ModelEvent[Plate]) error {
+		t.Errorf("Expected only the events of the subscribed model, got %+v", event)
+		return nil
+	})
+	Subscribe(ctrl.Config.(*Config).EventBus(), func(c *gin.Context, event ModelEvent[Car]) error {
+		return errors.New("the index is down")
+	})
+
+	doRequest(app, "PUT", "/cars/new", url.Values{"Name": {"golf"}})
+	doRequest(app, "POST", "/cars/1", url.Values{"Name": {"polo"}})
+	if w := doAs(app, "bob", "DELETE", "/cars/1"); w.Code != http.StatusOK {
+		t.Errorf("Expected the failed handler not to fail the request, got %d", w.Code)
+	}
+	if strings.Join(events, ";") != "created 1 golf by ;deleted 1 polo by bob" {
+		t.Errorf("Unexpected events %v", events)
+	}
+}
+
+func TestEventBus_AsyncSubscribers(t *testing.T) {
+	ctrl, app, _ := newTestCtrl[Car](t)
+	bus := ctrl.Config.(*Config).EventBus()
+	var mu sync.Mutex
+	events := []string{}
+	unsubscribe := SubscribeAsync(bus, func(c *gin.Context, event ModelEvent[Car]) error {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, fmt.Sprintf("%s %s", event.Event, event.Item.Name))
+		return nil
+	})
+
+	doRequest(app, "PUT", "/cars/new", url.Values{"Name": {"golf"}})
+	doRequest(app, "PATCH", "/cars/1", url.Values{"Name": {"polo"}})
+	bus.Wait()
+	unsubscribe()
+	doRequest(app, "PUT", "/cars/new", url.Values{"Name": {"up"}})
+	bus.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	// the async handlers run in their own goroutines, so their order is not guaranteed
+	slices.Sort(events)
+	if len(events) != 2 || events[0] != "created golf" || events[1] != "updated polo" {
+		t.Errorf("Unexpected events %v", events)
+	}
+}
@@ -11,11 +11,24 @@ import (
 type ICrudCtrl interface {
 	BasePath() string
 	GetModelName() string
+	// HasAction returns true if the action is exposed by the controller
+	HasAction(action Action) bool
+	// CustomActions returns the custom actions registered on the controller
+	CustomActions() []CustomAction
+	// HasChangeStream returns true if the controller streams the changes on the `/events` route
+	HasChangeStream() bool
+	// HasTrash returns true if the model is soft deleted and the controller has the trash, restore and purge routes
+	HasTrash() bool
+	// HasHistory returns true if the `/:id/history` route can read the audit entries of the items
+	HasHistory() bool
+	// RelationRoutes returns the last segment of the routes of the related items, e.g. `players` for `/:id/players`
+	RelationRoutes() []string
 
 	List(c *gin.Context)
 	Details(c *gin.Context)
 	Form(c *gin.Context)
 	Upsert(c *gin.Context)
+	Patch(c *gin.Context)
 	Delete(c *gin.Context)
 }
 
@@ -59,6 +72,45 @@ type IConfig interface {
 	AutoScaffold() bool
 }
 
+// IConfigOptions is the optional part of the configuration, with the settings of the features that were added after IConfig
+//
+// Config implements it. The controllers check for it with a type assertion, so the custom IConfig implementations keep working
+// and get the defaults of NewConfig(the lists are paged and searched, but nothing is scoped by tenant, audited, sent or published)
+type IConfigOptions interface {
+	// ErrorTemplate returns the template used to render the errors for the UI requests
+	ErrorTemplate() string
+
+	// HardDelete returns true if the items are permanently deleted even if the model supports soft deletes
+	HardDelete() bool
+
+	// TenantResolver returns the function that resolves the tenant of the request, or nil if the queries are not scoped by tenant
+	TenantResolver() TenantResolver
+
+	// TenantField returns the name of the model field that holds the tenant
+	TenantField() string
+
+	// AuditSink returns the sink of the audit entries, or nil if the changes are not audited
+	AuditSink() IAuditSink
+
+	// ActorResolver returns the function that resolves the user that makes the request
+	ActorResolver() ActorResolver
+
+	// Searcher returns the function that applies the `?q=` search of the lists
+	Searcher() Searcher
+
+	// Webhooks returns the queue of the webhooks, or nil if the changes are not sent to webhooks
+	Webhooks() IWebhookQueue
+
+	// EventBus returns the event bus that receives the changes of the controllers, or nil if the changes are not published
+	EventBus() *EventBus
+
+	// DefaultPageSize returns the page size of the lists when `$top` is not set, 0 if the lists are not paged by default
+	DefaultPageSize() int
+
+	// MaxPageSize returns the largest page size of the lists, 0 if it is not limited
+	MaxPageSize() int
+}
+
 // IResponseCapabilities is an interface that defines the capabilities of the response
 //
 // IConfig is already compliant with this interface
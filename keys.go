@@ -0,0 +1,109 @@
+package crudex
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// KeySeparator separates the values of a composite key in the routes, e.g. `/memberships/1,admin`
+const KeySeparator = ","
+
+// Key holds the values of the primary key of an item mapped by their column name
+//
+// It can be used directly as a gorm condition
+type Key map[string]interface{}
+
+// IKeyResolver converts the primary key of a model between its route and its model representation
+//
+// It makes the controller independent of the key type, so uint, string, uuid and composite keys are handled the same way
+type IKeyResolver[T IEntity] interface {
+	// Parse parses the route parameter into the key of the model
+	Parse(param string) (Key, error)
+	// Format returns the route parameter for the key of the item
+	Format(item *T) string
+	// Apply sets the key on the item
+	Apply(item *T, key Key) error
+}
+
+// SchemaKeyResolver is the default key resolver that uses the primary fields of the gorm schema of the model
+//
+// The route values are converted to the type of the primary fields, types that implement encoding.TextUnmarshaler
+// or sql.Scanner (like uuid.UUID) are supported as well. Composite keys are separated with `KeySeparator`
+type SchemaKeyResolver[T IEntity] struct {
+	fields []*schema.Field
+}
+
+// NewSchemaKeyResolver creates a key resolver from the gorm schema of the model
+func NewSchemaKeyResolver[T IEntity](db *gorm.DB) *SchemaKeyResolver[T] {
+	sch, err := parseSchema(new(T), db)
+	if err != nil {
+		panic(err)
+	}
+	return &SchemaKeyResolver[T]{fields: sch.PrimaryFields}
+}
+
+// Parse parses the route parameter into the key of the model
+//
+// The values are unescaped as query values, so both the keys of `Format` and the keys of the `urlquery` template function are accepted.
+// Only the composite keys are split with `KeySeparator`,
+// so the value of a single field key can contain the separator as well
+func (self *SchemaKeyResolver[T]) Parse(param string) (Key, error) {
+	values := []string{param}
+	if len(self.fields) > 1 {
+		values = strings.Split(param, KeySeparator)
+	}
+	if param == "" || len(values) != len(self.fields) {
+		return nil, NewHttpError(http.StatusBadRequest, fmt.Sprintf("Invalid key: %s", param))
+	}
+	key := Key{}
+	for i, field := range self.fields {
+		str, err := url.QueryUnescape(values[i])
+		if err != nil {
+			return nil, NewHttpError(http.StatusBadRequest, fmt.Sprintf("Invalid key: %s", param))
+		}
+		value, err := parseValue(field.FieldType, str)
+		if err != nil {
+			return nil, NewHttpError(http.StatusBadRequest, fmt.Sprintf("Invalid key: %s", param))
+		}
+		key[field.DBName] = value
+	}
+	return key, nil
+}
+
+// Format returns the route parameter for the key of the item
+//
+// Every value is escaped with `escapeKeyValue`, so the key can be used as a segment of a url
+func (self *SchemaKeyResolver[T]) Format(item *T) string {
+	val := reflect.ValueOf(item).Elem()
+	values := make([]string, len(self.fields))
+	for i, field := range self.fields {
+		value, _ := field.ValueOf(context.Background(), val)
+		values[i] = escapeKeyValue(fmt.Sprint(value))
+	}
+	return strings.Join(values, KeySeparator)
+}
+
+// escapeKeyValue escapes a value of a key with `url.PathEscape`, the `+` is escaped as well since the keys are unescaped as query values
+func escapeKeyValue(value string) string {
+	return strings.ReplaceAll(url.PathEscape(value), "+", "%2B")
+}
+
+// Apply sets the key on the item
+func (self *SchemaKeyResolver[T]) Apply(item *T, key Key) error {
+	val := reflect.ValueOf(item).Elem()
+	for _, field := range self.fields {
+		if value, ok := key[field.DBName]; ok {
+			if err := field.Set(context.Background(), val, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
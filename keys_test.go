@@ -0,0 +1,81 @@
+package crudex
+
+import (
+	"html"
+	"html/template"
+	"strings"
+	"testing"
+)
+
+type Slug string
+
+func (self *Slug) UnmarshalText(text []byte) error {
+	*self = Slug(strings.ToLower(string(text)))
+	return nil
+}
+
+type Article struct {
+	Slug  Slug `gorm:"primaryKey"`
+	Title string
+}
+
+func TestSchemaKeyResolver_ParsesTextUnmarshalers(t *testing.T) {
+	keys := NewSchemaKeyResolver[Article](nil)
+	key, err := keys.Parse("Hello-World")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if key["slug"] != Slug("hello-world") {
+		t.Errorf("Expected the key to be unmarshaled, got %v", key)
+	}
+	var article Article
+	if err := keys.Apply(&article, key); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if keys.Format(&article) != "hello-world" {
+		t.Errorf("Expected hello-world, got %s", keys.Format(&article))
+	}
+}
+
+func TestSchemaKeyResolver_RejectsInvalidKeys(t *testing.T) {
+	keys := NewSchemaKeyResolver[Car](nil)
+	for _, param := range []string{"", "abc", "1,2", "-1"} {
+		if _, err := keys.Parse(param); err == nil {
+			t.Errorf("Expected an error for '%s'", param)
+		}
+	}
+}
+
+func TestScaffoldDataModel_CompositeKey(t *testing.T) {
+	md := NewScaffoldDataModel(Membership{}, &ScaffoldDataModelConfigurator{})
+	if md.Key("") != "{{.TeamID | urlquery}},{{.UserID | urlquery}}" {
+		t.Errorf("Unexpected key expression %s", md.Key(""))
+	}
+	md = NewScaffoldDataModel(Car{}, &ScaffoldDataModelConfigurator{})
+	if md.Key(".Car") != "{{.Car.ID | urlquery}}" {
+		t.Errorf("Unexpected key expression %s", md.Key(".Car"))
+	}
+}
+
+func TestScaffoldDataModel_KeyIsEscaped(t *testing.T) {
+	md := NewScaffoldDataModel(Membership{}, &ScaffoldDataModelConfigurator{})
+	tmpl := template.Must(template.New("key").Parse(`<a hx-get="` + md.Key("") + `">`))
+	item := Membership{TeamID: 1, UserID: "a,b/c? d+e"}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, item); err != nil {
+		t.Fatalf("Error rendering the key: %s", err)
+	}
+	// the browser unescapes the html entities of the attribute
+	param := html.UnescapeString(strings.TrimSuffix(strings.TrimPrefix(out.String(), `<a hx-get="`), `">`))
+	if strings.ContainsAny(param, "/? ") || strings.Count(param, KeySeparator) != 1 {
+		t.Errorf("Expected the values to be escaped, got %s", param)
+	}
+	keys := NewSchemaKeyResolver[Membership](nil)
+	key, err := keys.Parse(param)
+	if err != nil || key["user_id"] != item.UserID {
+		t.Errorf("Expected the rendered key to be parsed back, got %v %v", key, err)
+	}
+	if key, err := keys.Parse(keys.Format(&item)); err != nil || key["user_id"] != item.UserID {
+		t.Errorf("Expected the formatted key to be parsed back, got %v %v", key, err)
+	}
+}
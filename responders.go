@@ -25,9 +25,74 @@ func Respond(c *gin.Context, data gin.H, templateName string) {
 // See Config for more information on the configuration
 func RespondWithConfig(status uint, c *gin.Context, data gin.H, templateName string, conf IConfig) {
 	if conf.LayoutDataFunc() != nil {
-		config.LayoutDataFunc()(c, data)
+		conf.LayoutDataFunc()(c, data)
+	}
+	_respondWithStatus(int(status), c, data, templateName, conf.LayoutName(), conf)
+}
+
+// RespondError is a function that responds with the error using the default configuration
+// See `RespondErrorWithConfig` for more information
+func RespondError(status int, c *gin.Context, err error) {
+	RespondErrorWithConfig(status, c, err, GetConfig())
+}
+
+// RespondErrorWithConfig is a function that responds with the error using the same content negotiation as RespondWithConfig
+//
+//   - API clients receive an RFC 7807 `application/problem+json` document
+//   - HTML and Htmx clients receive the error template of the configuration rendered with the problem data
+//     (Status, Title, Detail and Instance)
+func RespondErrorWithConfig(status int, c *gin.Context, err error, conf IConfig) {
+	problem := NewProblem(status, err)
+	problem.Instance = c.Request.URL.Path
+	switch negotiate(c, conf) {
+	case responseAPI:
+		c.Header("Content-Type", MIMEProblemJSON)
+		c.JSON(status, problem)
+	case responseUI:
+		data := gin.H{
+			"Status":   problem.Status,
+			"Title":    problem.Title,
+			"Detail":   problem.Detail,
+			"Instance": problem.Instance,
+			"Path":     c.Request.URL.Path,
+		}
+		if conf.LayoutDataFunc() != nil {
+			conf.LayoutDataFunc()(c, data)
+		}
+		_respondWithStatus(status, c, data, configOptions(conf).ErrorTemplate(), conf.LayoutName(), conf)
+	default:
+		c.String(status, "Error: %s", err.Error())
+	}
+}
+
+type responseKind int
+
+const (
+	responseNone responseKind = iota
+	responseAPI
+	responseUI
+)
+
+// negotiate decides how to respond based on the request accept header and the capabilities
+//
+// If the request accepts application/json or has no accept header the response is an API response.
+// If the request accepts text/html or */* the response is a UI response.
+// If only one of the capabilities is enabled that one is used regardless of the accept header
+func negotiate(c *gin.Context, capabilites IResponseCapabilities) responseKind {
+	var isNone = c.Request.Header.Get("Accept") == ""                    // no accept header, we default to json
+	var isStar = strings.Contains(c.Request.Header.Get("Accept"), "*/*") // we default to html
+	var isApi = strings.Contains(c.Request.Header.Get("Accept"), "application/json")
+	var isUi = strings.Contains(c.Request.Header.Get("Accept"), "text/html")
+	hasUI := capabilites.HasUI()
+	hasAPI := capabilites.HasAPI()
+	switch {
+	case hasAPI && (isApi || isNone || !hasUI):
+		return responseAPI
+	case hasUI && (isUi || isStar || !hasAPI):
+		return responseUI
+	default:
+		return responseNone
 	}
-	_respond(c, data, templateName, conf.LayoutName(), conf)
 }
 
 // _respond is a helper function that renders the data based on the request accept header and the Hx-Request header
@@ -43,27 +108,25 @@ func RespondWithConfig(status uint, c *gin.Context, data gin.H, templateName str
 //
 // If the request does not match the capabilities it will write an error to the response
 func _respond(c *gin.Context, data gin.H, templateName string, layout string, capabilites IResponseCapabilities) {
-	var isNone = c.Request.Header.Get("Accept") == ""                    // no accept header, we default to json
-	var isStar = strings.Contains(c.Request.Header.Get("Accept"), "*/*") // we default to html
-	var isApi = strings.Contains(c.Request.Header.Get("Accept"), "application/json")
-	var isUi = strings.Contains(c.Request.Header.Get("Accept"), "text/html")
+	_respondWithStatus(http.StatusOK, c, data, templateName, layout, capabilites)
+}
+
+// _respondWithStatus is the same as _respond but it responds with the given status
+func _respondWithStatus(status int, c *gin.Context, data gin.H, templateName string, layout string, capabilites IResponseCapabilities) {
 	var isHxRequest = c.Request.Header.Get("Hx-Request") == "true"
-	hasUI := capabilites.HasUI()
-	hasAPI := capabilites.HasAPI()
 	useLayoutOnFullPageLoad := capabilites.EnableLayoutOnNonHxRequest()
 	layoutEnabled := layout != "" && useLayoutOnFullPageLoad
 
-	switch {
-
-	case hasAPI && (isApi || isNone || !hasUI):
-		c.JSON(http.StatusOK, data)
-	case hasUI && (isUi || isStar || !hasAPI):
+	switch negotiate(c, capabilites) {
+	case responseAPI:
+		c.JSON(status, data)
+	case responseUI:
 		if isHxRequest || !layoutEnabled {
 			data["IsLayoutEnabled"] = false
-			c.HTML(http.StatusOK, templateName, data)
+			c.HTML(status, templateName, data)
 		} else {
 			data["IsLayoutEnabled"] = true
-			c.HTML(http.StatusOK, templateName, data)
+			c.HTML(status, templateName, data)
 		}
 	default:
 		err := fmt.Errorf("No capability to respond for header Accept: %s", c.Request.Header.Get("Accept"))
@@ -1,6 +1,7 @@
 package crudex
 
 import (
+	"errors"
 	"html/template"
 	"net/http/httptest"
 	"testing"
@@ -148,3 +149,21 @@ func faker() (*gin.Context, *httptest.ResponseRecorder) {
 	c.Request = req
 	return c, w
 }
+
+func TestRespondError_HtmlRendersTheErrorTemplate(t *testing.T) {
+	tmpl := template.Must(template.New("error.html").Parse(`<p>{{.Status}} {{.Detail}}</p>`))
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, e := gin.CreateTestContext(w)
+	e.HTMLRender = ginrender.HTMLProduction{Template: tmpl}
+	c.Request = httptest.NewRequest("GET", "/cars/1", nil)
+	c.Request.Header.Set("Accept", "text/html")
+
+	RespondErrorWithConfig(404, c, errors.New("missing"), NewConfig())
+	if w.Code != 404 {
+		t.Errorf("Expected 404, got %d", w.Code)
+	}
+	if w.Body.String() != "<p>404 missing</p>" {
+		t.Errorf("Expected the error template to be rendered, got %s", w.Body.String())
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"strings"
 	"text/template"
+	"time"
 
 	"github.com/halicea/crudex/shared"
 )
@@ -22,6 +24,24 @@ var List string
 //go:embed scaffold_templates/form.html
 var Form string
 
+//go:embed scaffold_templates/error.html
+var Error string
+
+//go:embed scaffold_templates/trash.html
+var Trash string
+
+//go:embed scaffold_templates/history.html
+var History string
+
+//go:embed scaffold_templates/import.html
+var Import string
+
+//go:embed scaffold_templates/related.html
+var Related string
+
+//go:embed scaffold_templates/openapi.yaml
+var OpenAPI string
+
 type ScaffoldMap struct {
 	templates map[string]func() string
 	funcMap   template.FuncMap
@@ -91,6 +111,36 @@ func (self *ScaffoldMap) WithFormScaffold(value func() string) *ScaffoldMap {
 	return self.Set(shared.ScaffoldTemplateForm.String(), value)
 }
 
+// WithTrashScaffold sets the scaffold template function that generates the trash[T] template
+func (self *ScaffoldMap) WithTrashScaffold(value func() string) *ScaffoldMap {
+	return self.Set(shared.ScaffoldTemplateTrash.String(), value)
+}
+
+// WithHistoryScaffold sets the scaffold template function that generates the history[T] template
+func (self *ScaffoldMap) WithHistoryScaffold(value func() string) *ScaffoldMap {
+	return self.Set(shared.ScaffoldTemplateHistory.String(), value)
+}
+
+// WithImportScaffold sets the scaffold template function that generates the import[T] template
+func (self *ScaffoldMap) WithImportScaffold(value func() string) *ScaffoldMap {
+	return self.Set(shared.ScaffoldTemplateImport.String(), value)
+}
+
+// WithRelatedScaffold sets the scaffold template function that generates the related[T] template, the page of a has-many relation
+func (self *ScaffoldMap) WithRelatedScaffold(value func() string) *ScaffoldMap {
+	return self.Set(shared.ScaffoldTemplateRelated.String(), value)
+}
+
+// WithOpenAPIScaffold sets the scaffold template function that generates the API description of the controllers
+func (self *ScaffoldMap) WithOpenAPIScaffold(value func() string) *ScaffoldMap {
+	return self.Set(shared.ScaffoldTemplateOpenAPI.String(), value)
+}
+
+// WithErrorScaffold sets the scaffold template function that generates the template used to render the errors
+func (self *ScaffoldMap) WithErrorScaffold(value func() string) *ScaffoldMap {
+	return self.Set(shared.ScaffoldTemplateError.String(), value)
+}
+
 // WithLayoutScaffold sets the scaffold template function that generates the layout template used for listing all the models
 func (self *ScaffoldMap) WithLayoutScaffold(value func() string) *ScaffoldMap {
 	return self.Set(shared.ScaffoldTemplateLayout.String(), value)
@@ -111,8 +161,15 @@ func New() *ScaffoldMap {
 		Set(shared.ScaffoldTemplateList.String(), func() string { return ReadContentsOrDefault("scaffolds/list.html", List, true) }).
 		Set(shared.ScaffoldTemplateDetail.String(), func() string { return ReadContentsOrDefault("scaffolds/detail.html", Detail, true) }).
 		Set(shared.ScaffoldTemplateForm.String(), func() string { return ReadContentsOrDefault("scaffolds/form.html", Form, true) }).
+		Set(shared.ScaffoldTemplateError.String(), func() string { return ReadContentsOrDefault("scaffolds/error.html", Error, true) }).
+		Set(shared.ScaffoldTemplateTrash.String(), func() string { return ReadContentsOrDefault("scaffolds/trash.html", Trash, true) }).
+		Set(shared.ScaffoldTemplateHistory.String(), func() string { return ReadContentsOrDefault("scaffolds/history.html", History, true) }).
+		Set(shared.ScaffoldTemplateImport.String(), func() string { return ReadContentsOrDefault("scaffolds/import.html", Import, true) }).
+		Set(shared.ScaffoldTemplateRelated.String(), func() string { return ReadContentsOrDefault("scaffolds/related.html", Related, true) }).
+		Set(shared.ScaffoldTemplateOpenAPI.String(), func() string { return ReadContentsOrDefault("scaffolds/openapi.yaml", OpenAPI, true) }).
 		WithFuncMap(template.FuncMap{
-			"RenderInputType": RenderInputType,
+			"RenderInputType":   RenderInputType,
+			"RenderFilterInput": RenderFilterInput,
 		})
 }
 
@@ -163,6 +220,13 @@ func (self *ScaffoldMap) PrintAll() {
 	}
 }
 
+// inputValue returns the template expression of the input value
+//
+// The submitted values are rendered when they are available, so the form keeps the user input when it is re-rendered with errors
+func inputValue(modelName string, field reflect.StructField) string {
+	return fmt.Sprintf(`{{if $.Values}}{{$.Values.Get "%s"}}{{else}}{{.%s.%s}}{{end}}`, field.Name, modelName, field.Name)
+}
+
 // RenderInputType is a helper function that renders an input based on the type of the field.
 //
 // This function is part of the default FuncMap that is passed to the scaffold templates.
@@ -178,11 +242,11 @@ func RenderInputType(modelName string, field reflect.StructField) string {
 	case reflect.String:
 		switch inpTag {
 		case "":
-			return fmt.Sprintf(`<input type="text" name="%s"%s value="{{.%s.%s}}"/>`, field.Name, placeholder, modelName, field.Name)
+			return fmt.Sprintf(`<input type="text" name="%s"%s value="%s"/>`, field.Name, placeholder, inputValue(modelName, field))
 		case shared.INPUT_MARKDOWN.String(), shared.INPUT_HTML.String(), shared.INPUT_WYSIWYG.String(), shared.INPUT_TEXT.String():
-			return fmt.Sprintf(`<input type="textarea" name="%s"%s value="{{.%s.%s}}"/>`, field.Name, placeholder, modelName, field.Name)
+			return fmt.Sprintf(`<input type="textarea" name="%s"%s value="%s"/>`, field.Name, placeholder, inputValue(modelName, field))
 		case shared.INPUT_DATETIME.String():
-			return fmt.Sprintf(`<input type="datetime" name="%s"%s value="{{.%s.%s}}"/>`, field.Name, placeholder, modelName, field.Name)
+			return fmt.Sprintf(`<input type="datetime" name="%s"%s value="%s"/>`, field.Name, placeholder, inputValue(modelName, field))
 		default:
 			panic(fmt.Sprintf("Unsupported input type '%s' specified for %s/%s", inpTag, modelName, field.Name))
 		}
@@ -190,14 +254,53 @@ func RenderInputType(modelName string, field reflect.StructField) string {
 		//TODO: need to make this work better
 		switch inpTag {
 		case "":
-			return fmt.Sprintf(`<input type="number" name="%s"%s value="{{.%s.%s}}"/>`, field.Name, placeholder, modelName, field.Name)
+			return fmt.Sprintf(`<input type="number" name="%s"%s value="%s"/>`, field.Name, placeholder, inputValue(modelName, field))
 		default:
-			return fmt.Sprintf(`<input type="number" name="%s"%s value="{{.%s.%s}}"/>`, field.Name, placeholder, modelName, field.Name)
+			return fmt.Sprintf(`<input type="number" name="%s"%s value="%s"/>`, field.Name, placeholder, inputValue(modelName, field))
 		}
 
 	case reflect.Bool:
-		return fmt.Sprintf(`<input type="checkbox" name="%s"%s value="{{.%s.%s}}"/>`, field.Name, placeholder, modelName, field.Name)
+		// the hidden input submits false for an unchecked box, the value of the checkbox follows it and wins when it is checked
+		checked := fmt.Sprintf(`{{if $.Values}}{{if gt (len (index $.Values "%s")) 1}} checked{{end}}{{else if .%s.%s}} checked{{end}}`,
+			field.Name, modelName, field.Name)
+		return fmt.Sprintf(`<input type="hidden" name="%s" value="false"/><input type="checkbox" name="%s"%s value="true"%s/>`,
+			field.Name, field.Name, placeholder, checked)
 	}
 
 	panic(fmt.Sprintf("unsupported type: %s for field %s", field.Type.Kind().String(), field.Name))
 }
+
+// RenderFilterInput is a helper function that renders the filter inputs of a field in the list template.
+//
+// This function is part of the default FuncMap that is passed to the scaffold templates.
+// The text fields are filtered with a search input, the numbers and dates with a range and the booleans with a select.
+// The inputs have the `<modelName>-query` class, so they are included in the requests of the list
+func RenderFilterInput(modelName string, field reflect.StructField) string {
+	name := fmt.Sprintf("f.%s", field.Name)
+	input := func(typ string, param string, placeholder string) string {
+		return fmt.Sprintf(`<input type="%s" id="%s-%s" class="%s-query" name="%s" value="{{$.Query.Get "%s"}}" placeholder="%s" aria-label="%s"/>`,
+			typ, modelName, strings.ReplaceAll(param, ".", "-"), modelName, param, param, placeholder, placeholder)
+	}
+	typ := field.Type
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ == reflect.TypeOf(time.Time{}) {
+		return input("date", name+".from", field.Name+" from") + input("date", name+".to", field.Name+" to")
+	}
+	switch typ.Kind() {
+	case reflect.String:
+		return input("search", name, field.Name)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Float32, reflect.Float64:
+		return input("number", name+".from", field.Name+" from") + input("number", name+".to", field.Name+" to")
+	case reflect.Bool:
+		option := func(value string, label string) string {
+			return fmt.Sprintf(`<option value="%s"{{if eq ($.Query.Get "%s") "%s"}} selected{{end}}>%s</option>`, value, name, value, label)
+		}
+		return fmt.Sprintf(`<select id="%s-%s" class="%s-query" name="%s" aria-label="%s">%s%s%s</select>`,
+			modelName, strings.ReplaceAll(name, ".", "-"), modelName, name, field.Name,
+			option("", field.Name+": any"), option("true", "Yes"), option("false", "No"))
+	}
+	panic(fmt.Sprintf("unsupported filter type: %s for field %s", field.Type.String(), field.Name))
+}
@@ -4,6 +4,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 type TestStruct struct {
@@ -11,6 +12,7 @@ type TestStruct struct {
 	Num  int32
 	Html string `crud-input:"html" crud-placeholder:"Enter some HTML"`
 	Date string `crud-input:"datetime"`
+	Flag bool
 }
 
 func TestRender_InputTypes(t *testing.T) {
@@ -19,6 +21,7 @@ func TestRender_InputTypes(t *testing.T) {
 		"Num":  "type=\"number\"",
 		"Html": "type=\"textarea\"",
 		"Date": "type=\"datetime\"",
+		"Flag": "<input type=\"hidden\" name=\"Flag\" value=\"false\"/><input type=\"checkbox\" name=\"Flag\" value=\"true\"",
 	}
 
 	tt := reflect.TypeFor[TestStruct]()
@@ -31,3 +34,35 @@ func TestRender_InputTypes(t *testing.T) {
 		}
 	}
 }
+
+type FilterStruct struct {
+	Str    string
+	Num    int32
+	Flag   bool
+	Time   time.Time
+	PtrDay *time.Time
+}
+
+func TestRender_FilterInputs(t *testing.T) {
+	var expected = map[string][]string{
+		"Str":    {`type="search"`, `name="f.Str"`},
+		"Num":    {`type="number"`, `name="f.Num.from"`, `name="f.Num.to"`},
+		"Flag":   {`<select`, `name="f.Flag"`, `value="true"`, `value="false"`},
+		"Time":   {`type="date"`, `name="f.Time.from"`, `name="f.Time.to"`},
+		"PtrDay": {`type="date"`, `name="f.PtrDay.from"`},
+	}
+
+	tt := reflect.TypeFor[FilterStruct]()
+	for i := 0; i < tt.NumField(); i++ {
+		field := tt.Field(i)
+		res := RenderFilterInput("FilterStructList", field)
+		for _, exp := range expected[field.Name] {
+			if !strings.Contains(res, exp) {
+				t.Errorf("Expected %s in %s", exp, res)
+			}
+		}
+		if !strings.Contains(res, `class="FilterStructList-query"`) {
+			t.Errorf("Expected the query class in %s", res)
+		}
+	}
+}
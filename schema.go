@@ -0,0 +1,71 @@
+package crudex
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// schemaCache caches the parsed gorm schemas of the models
+var schemaCache = &sync.Map{}
+
+// parseSchema parses the gorm schema of the model using the naming strategy of the database
+//
+// If the database is nil, the default gorm naming strategy is used
+func parseSchema(model interface{}, db *gorm.DB) (*schema.Schema, error) {
+	var namer schema.Namer = schema.NamingStrategy{}
+	if db != nil && db.Config != nil && db.NamingStrategy != nil {
+		namer = db.NamingStrategy
+	}
+	return schema.Parse(model, schemaCache, namer)
+}
+
+// Schema returns the gorm schema of the model managed by the controller
+func (self *CrudCtrl[T]) Schema() *schema.Schema {
+	sch, err := parseSchema(new(T), self.Db)
+	if err != nil {
+		panic(err)
+	}
+	return sch
+}
+
+// changedFields returns the database fields that differ between the two items
+func changedFields(sch *schema.Schema, before, after interface{}) []*schema.Field {
+	beforeVal := reflect.Indirect(reflect.ValueOf(before))
+	afterVal := reflect.Indirect(reflect.ValueOf(after))
+	changed := []*schema.Field{}
+	for _, field := range sch.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		beforeField, _ := field.ValueOf(context.Background(), beforeVal)
+		afterField, _ := field.ValueOf(context.Background(), afterVal)
+		if !valuesEqual(beforeField, afterField) {
+			changed = append(changed, field)
+		}
+	}
+	return changed
+}
+
+// valuesEqual compares two field values the way the database sees them
+//
+// Valuers are compared by their database value, and times are compared as instants
+func valuesEqual(a, b interface{}) bool {
+	if av, ok := a.(driver.Valuer); ok {
+		if bv, ok := b.(driver.Valuer); ok {
+			a, _ = av.Value()
+			b, _ = bv.Value()
+		}
+	}
+	if at, ok := a.(time.Time); ok {
+		if bt, ok := b.(time.Time); ok {
+			return at.Equal(bt)
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
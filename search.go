@@ -0,0 +1,131 @@
+package crudex
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Searcher applies the free text search of the `?q=` query parameter on the query
+//
+// It receives the searchable fields of the model and the terms of the search, every term should match at least one of the fields
+type Searcher func(db *gorm.DB, sch *schema.Schema, fields []*schema.Field, terms []string) *gorm.DB
+
+// SearchLike searches the fields with `LIKE`, it works on every database but it can not use the indexes
+//
+// The search is case insensitive and every term has to be found in at least one of the fields.
+// Only the string fields are searched, nothing is found if none of the fields is a string
+func SearchLike() Searcher {
+	return func(db *gorm.DB, sch *schema.Schema, fields []*schema.Field, terms []string) *gorm.DB {
+		columns := []clause.Column{}
+		for _, field := range fields {
+			if isStringField(field) {
+				columns = append(columns, clause.Column{Table: sch.Table, Name: field.DBName})
+			}
+		}
+		if len(columns) == 0 {
+			return db.Where("1 = 0")
+		}
+		for _, term := range terms {
+			matches := make([]clause.Expression, len(columns))
+			for i, column := range columns {
+				matches[i] = likeExpr(column, term)
+			}
+			db = db.Where(clause.Or(matches...))
+		}
+		return db
+	}
+}
+
+// SearchFTS5 searches with a SQLite FTS5 table that indexes the items of the model, its rowid is the primary key of the model
+//
+// The table is named `<table>_fts` if the name is empty, it is not created by crudex, e.g.
+//
+//	CREATE VIRTUAL TABLE cars_fts USING fts5(name, description, content='cars', content_rowid='id');
+func SearchFTS5(table string) Searcher {
+	return func(db *gorm.DB, sch *schema.Schema, fields []*schema.Field, terms []string) *gorm.DB {
+		fts := table
+		if fts == "" {
+			fts = fmt.Sprintf("%s_fts", sch.Table)
+		}
+		// every term is quoted, so the search text can not use the FTS5 query syntax
+		phrases := make([]string, len(terms))
+		for i, term := range terms {
+			phrases[i] = fmt.Sprintf(`"%s"`, strings.ReplaceAll(term, `"`, `""`))
+		}
+		return db.Where(clause.Expr{
+			SQL: "? IN (SELECT rowid FROM ? WHERE ? MATCH ?)",
+			Vars: []interface{}{
+				clause.Column{Table: sch.Table, Name: sch.PrioritizedPrimaryField.DBName},
+				clause.Table{Name: fts}, clause.Table{Name: fts}, strings.Join(phrases, " "),
+			},
+		})
+	}
+}
+
+// SearchTsvector searches with the Postgres full text search on the searchable fields, with the given text search configuration(e.g. `english`)
+//
+// Create an index on the same expression to make it fast, e.g.
+//
+//	CREATE INDEX cars_search ON cars USING gin(to_tsvector('english', concat_ws(' ', "cars"."name", "cars"."description")));
+func SearchTsvector(language string) Searcher {
+	return func(db *gorm.DB, sch *schema.Schema, fields []*schema.Field, terms []string) *gorm.DB {
+		columns := make([]string, len(fields))
+		vars := []interface{}{language}
+		for i, field := range fields {
+			columns[i] = "?"
+			vars = append(vars, clause.Column{Table: sch.Table, Name: field.DBName})
+		}
+		vars = append(vars, language, strings.Join(terms, " "))
+		return db.Where(clause.Expr{
+			SQL:  fmt.Sprintf("to_tsvector(?, concat_ws(' ', %s)) @@ plainto_tsquery(?, ?)", strings.Join(columns, ", ")),
+			Vars: vars,
+		})
+	}
+}
+
+// SearchFields returns the fields that are searched with `?q=`
+//
+// These are the fields tagged with `crud-search`, or all the string fields if none of the fields is tagged
+func (self *CrudCtrl[T]) SearchFields() []*schema.Field {
+	tagged, strs := []*schema.Field{}, []*schema.Field{}
+	for _, field := range self.Schema().Fields {
+		if field.DBName == "" {
+			continue
+		}
+		if _, ok := field.Tag.Lookup("crud-search"); ok {
+			tagged = append(tagged, field)
+		} else if field.FieldType.Kind() == reflect.String {
+			strs = append(strs, field)
+		}
+	}
+	if len(tagged) > 0 {
+		return tagged
+	}
+	return strs
+}
+
+// Search applies the free text search of the `?q=` query parameter with the Searcher of the configuration
+//
+// The query is returned as it is if there is no search. An *HttpError with status 400 is returned if the model has no searchable fields
+func (self *CrudCtrl[T]) Search(c *gin.Context, db *gorm.DB) (*gorm.DB, error) {
+	terms := strings.Fields(c.Query("q"))
+	if len(terms) == 0 {
+		return db, nil
+	}
+	fields := self.SearchFields()
+	if len(fields) == 0 {
+		return nil, NewHttpError(http.StatusBadRequest, fmt.Sprintf("%s can not be searched", self.ModelName))
+	}
+	searcher := self.options().Searcher()
+	if searcher == nil {
+		searcher = SearchLike()
+	}
+	return searcher(db, self.Schema(), fields, terms), nil
+}
@@ -52,4 +52,9 @@ const (
 	ScaffoldTemplateDetail                              //detail
 	ScaffoldTemplateForm                                //form
 	ScaffoldTemplateOpenAPI                             //openapi
+	ScaffoldTemplateError                               //error
+	ScaffoldTemplateTrash                               //trash
+	ScaffoldTemplateHistory                             //history
+	ScaffoldTemplateImport                              //import
+	ScaffoldTemplateRelated                             //related
 )
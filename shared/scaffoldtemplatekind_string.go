@@ -13,11 +13,16 @@ func _() {
 	_ = x[ScaffoldTemplateDetail-2]
 	_ = x[ScaffoldTemplateForm-3]
 	_ = x[ScaffoldTemplateOpenAPI-4]
+	_ = x[ScaffoldTemplateError-5]
+	_ = x[ScaffoldTemplateTrash-6]
+	_ = x[ScaffoldTemplateHistory-7]
+	_ = x[ScaffoldTemplateImport-8]
+	_ = x[ScaffoldTemplateRelated-9]
 }
 
-const _ScaffoldTemplateKind_name = "layoutlistdetailformopenapi"
+const _ScaffoldTemplateKind_name = "layoutlistdetailformopenapierrortrashhistoryimportrelated"
 
-var _ScaffoldTemplateKind_index = [...]uint8{0, 6, 10, 16, 20, 27}
+var _ScaffoldTemplateKind_index = [...]uint8{0, 6, 10, 16, 20, 27, 32, 37, 44, 50, 57}
 
 func (i ScaffoldTemplateKind) String() string {
 	if i < 0 || i >= ScaffoldTemplateKind(len(_ScaffoldTemplateKind_index)-1) {
@@ -7,6 +7,7 @@ import (
 	"reflect"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/halicea/crudex/shared"
@@ -49,6 +50,41 @@ type ScaffoldDataModel struct {
 
 	// AllFields is a slice of reflect.StructField that represent all the fields of the model
 	AllFields []reflect.StructField
+
+	// KeyFields is a slice of reflect.StructField that represent the primary key fields of the model
+	KeyFields []reflect.StructField
+
+	// DateFields is a slice of reflect.StructField that represent the time fields of the model, including the embedded ones(e.g. CreatedAt)
+	DateFields []reflect.StructField
+
+	// SoftDelete is true if the model supports soft deletes, so it has a trash
+	SoftDelete bool
+}
+
+// Key returns the template expression that renders the route key of the model
+//
+// The prefix is the path to the model in the template data, e.g. `Key ""` renders `{{.ID | urlquery}}`
+// and `Key ".Car"` renders `{{.Car.ID | urlquery}}`. The values are escaped with `urlquery`, so they are parsed the same way as
+// the keys of `SchemaKeyResolver.Format`, and the values of composite keys are separated with `KeySeparator`
+func (md *ScaffoldDataModel) Key(prefix string) string {
+	if len(md.KeyFields) == 0 {
+		return fmt.Sprintf("{{%s.ID | urlquery}}", prefix)
+	}
+	parts := make([]string, len(md.KeyFields))
+	for i, field := range md.KeyFields {
+		parts[i] = fmt.Sprintf("{{%s.%s | urlquery}}", prefix, field.Name)
+	}
+	return strings.Join(parts, KeySeparator)
+}
+
+// HasField returns true if the field with the given name is one of the scaffolded fields
+func (md *ScaffoldDataModel) HasField(name string) bool {
+	for _, field := range md.Fields {
+		if field.Name == name {
+			return true
+		}
+	}
+	return false
 }
 
 // ScaffoldLayoutDataModel is a struct that holds the data needed to scaffold the layout template
@@ -61,6 +97,18 @@ type ScaffoldLayoutDataModel struct {
 type ScaffoldMenuItem struct {
 	Title string
 	Path  string
+	// Actions are the custom actions of the controller
+	Actions []CustomAction
+	// ChangeStream is true if the controller streams its changes on the `/events` route
+	ChangeStream bool
+	// HasAction returns true if the action is exposed by the controller, e.g. `[[if call .HasAction "delete"]]`
+	HasAction func(action string) bool
+	// Trash is true if the controller has the trash, restore and purge routes
+	Trash bool
+	// History is true if the controller shows the audit history of the items
+	History bool
+	// Relations are the last segments of the routes of the related items
+	Relations []string
 }
 
 // ScaffoldDataModelConfigurator is a struct that is used to create a ModelDescriptor
@@ -119,6 +167,24 @@ func NewScaffoldDataModel(data interface{}, opts *ScaffoldDataModelConfigurator)
 		}
 		fields = append(fields, field)
 	}
+	keyFields := []reflect.StructField{}
+	dateFields := []reflect.StructField{}
+	softDelete := false
+	if sch, err := parseSchema(data, nil); err == nil {
+		for _, field := range sch.PrimaryFields {
+			keyFields = append(keyFields, field.StructField)
+		}
+		for _, field := range sch.Fields {
+			typ := field.FieldType
+			if typ.Kind() == reflect.Pointer {
+				typ = typ.Elem()
+			}
+			if field.DBName != "" && typ == reflect.TypeOf(time.Time{}) {
+				dateFields = append(dateFields, field.StructField)
+			}
+		}
+		softDelete = softDeleteField(sch) != nil
+	}
 	fileName := templateName
 	if opts.RootDir != "" {
 		fileName = fmt.Sprintf("%s/%s", opts.RootDir, templateName)
@@ -129,6 +195,9 @@ func NewScaffoldDataModel(data interface{}, opts *ScaffoldDataModelConfigurator)
 		Name:             modelName,
 		Fields:           fields,
 		AllFields:        allFields,
+		KeyFields:        keyFields,
+		DateFields:       dateFields,
+		SoftDelete:       softDelete,
 	}
 }
 
@@ -204,6 +273,68 @@ func GenFormTmpl(data interface{}, rootDir string) {
 	}
 }
 
+// GenTrashTmpl generates the template that lists the soft deleted items of the model
+func GenTrashTmpl(data interface{}, rootDir string) {
+	err := NewScaffoldDataModel(data, &ScaffoldDataModelConfigurator{
+		RootDir:            rootDir,
+		TemplateNameSuffix: "-trash",
+		ModelNameSuffix:    "List",
+		TemplateExtension:  ".html",
+	}).Flush(_scaffoldFor(shared.ScaffoldTemplateTrash), config.ScaffoldStrategy())
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// GenImportTmpl generates the template that uploads a file of items and shows the report of the import
+func GenImportTmpl(data interface{}, rootDir string) {
+	err := NewScaffoldDataModel(data, &ScaffoldDataModelConfigurator{
+		RootDir:            rootDir,
+		TemplateNameSuffix: "-import",
+		TemplateExtension:  ".html",
+	}).Flush(_scaffoldFor(shared.ScaffoldTemplateImport), config.ScaffoldStrategy())
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// GenRelatedTmpl generates the template that lists the items of the model as a has-many relation of another model
+func GenRelatedTmpl(data interface{}, rootDir string) {
+	err := NewScaffoldDataModel(data, &ScaffoldDataModelConfigurator{
+		RootDir:            rootDir,
+		TemplateNameSuffix: "-related",
+		ModelNameSuffix:    "List",
+		TemplateExtension:  ".html",
+	}).Flush(_scaffoldFor(shared.ScaffoldTemplateRelated), config.ScaffoldStrategy())
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// GenHistoryTmpl generates the template that shows the audit history of an item of the model
+func GenHistoryTmpl(data interface{}, rootDir string) {
+	err := NewScaffoldDataModel(data, &ScaffoldDataModelConfigurator{
+		RootDir:            rootDir,
+		TemplateNameSuffix: "-history",
+		TemplateExtension:  ".html",
+	}).Flush(_scaffoldFor(shared.ScaffoldTemplateHistory), config.ScaffoldStrategy())
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// GenErrorTmpl generates the template that is used to render the errors for the UI requests
+func GenErrorTmpl(fileName string) {
+	err := flushScaffold(fileName, shared.ScaffoldTemplateError, ScaffoldLayoutDataModel{TemplateFileName: fileName})
+	if err != nil {
+		panic(err)
+	}
+}
+
 func GenLayout(fileName string, controllers []ICrudCtrl) {
 	if !shouldScaffold(config.ScaffoldStrategy(), fileName) {
 		if gin.IsDebugging() {
@@ -218,6 +349,9 @@ func GenLayout(fileName string, controllers []ICrudCtrl) {
 	}
 
 	for _, ctrl := range controllers {
+		if !ctrl.HasAction(ActionList) {
+			continue
+		}
 		data.Menu = append(data.Menu, ScaffoldMenuItem{
 			Title: ctrl.GetModelName(),
 			Path:  ctrl.BasePath(),
@@ -254,7 +388,14 @@ func GenOpenAPI(fileName string, controllers []ICrudCtrl) {
 	data := []ScaffoldMenuItem{}
 	for _, ctrl := range controllers {
 		data = append(data, ScaffoldMenuItem{
-			Title: ctrl.GetModelName(),
+			Title:        ctrl.GetModelName(),
+			Path:         ctrl.BasePath(),
+			Actions:      ctrl.CustomActions(),
+			ChangeStream: ctrl.HasChangeStream(),
+			HasAction:    func(action string) bool { return ctrl.HasAction(Action(action)) },
+			Trash:        ctrl.HasTrash(),
+			History:      ctrl.HasHistory(),
+			Relations:    ctrl.RelationRoutes(),
 		})
 	}
 	tmpl := template.Must(template.New(filepath.Base(fileName)).
@@ -277,6 +418,29 @@ func GenOpenAPI(fileName string, controllers []ICrudCtrl) {
 	}
 }
 
+// flushScaffold renders the scaffold template of the given kind with the data and writes it in the file
+//
+// It respects the scaffold strategy of the default configuration
+func flushScaffold(fileName string, kind shared.ScaffoldTemplateKind, data interface{}) error {
+	if !shouldScaffold(config.ScaffoldStrategy(), fileName) {
+		if gin.IsDebugging() {
+			fmt.Printf("Skipping scaffold of %s\n", fileName)
+		}
+		return nil
+	}
+	tmpl := template.Must(template.New(filepath.Base(fileName)).
+		Delims("[[", "]]").
+		Funcs(config.ScaffoldMap().FuncMap()).
+		Parse(_scaffoldFor(kind)))
+
+	tmplFile, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer tmplFile.Close()
+	return tmpl.Execute(tmplFile, data)
+}
+
 func shouldScaffold(strategy ScaffoldStrategy, fileName string) bool {
 	switch strategy {
 	case ScaffoldStrategyAlways:
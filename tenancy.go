@@ -0,0 +1,123 @@
+package crudex
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// TenantResolver resolves the tenant of the request
+//
+// Returning a nil or empty tenant rejects the request with 403, an *HttpError is sent to the client with its own status
+type TenantResolver func(c *gin.Context) (interface{}, error)
+
+// TenantFromHeader resolves the tenant from the request header with the given name, e.g. `X-Tenant-ID`
+func TenantFromHeader(name string) TenantResolver {
+	return func(c *gin.Context) (interface{}, error) {
+		return c.GetHeader(name), nil
+	}
+}
+
+// TenantFromSubdomain resolves the tenant from the first label of the request host, e.g. `acme` for `acme.example.com`
+func TenantFromSubdomain() TenantResolver {
+	return func(c *gin.Context) (interface{}, error) {
+		host := c.Request.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		labels := strings.Split(host, ".")
+		if len(labels) < 3 {
+			return nil, nil
+		}
+		return labels[0], nil
+	}
+}
+
+// TenantFromContext resolves the tenant from the value of the gin context with the given key
+//
+// It is meant to be used with a session or authentication middleware that sets the tenant of the user
+func TenantFromContext(key string) TenantResolver {
+	return func(c *gin.Context) (interface{}, error) {
+		value, _ := c.Get(key)
+		return value, nil
+	}
+}
+
+// tenantModel is implemented by the models of the package that name their own tenant field,
+// so they are scoped by tenant whatever the tenant field of the configuration is
+type tenantModel interface {
+	tenantField() string
+}
+
+// TenantField returns the tenant field of the model, or nil if the model is not scoped by tenant
+func (self *CrudCtrl[T]) TenantField() *schema.Field {
+	if self.options().TenantResolver() == nil {
+		return nil
+	}
+	name := self.options().TenantField()
+	if model, ok := any(new(T)).(tenantModel); ok {
+		name = model.tenantField()
+	}
+	return self.Schema().LookUpField(name)
+}
+
+// Tenant resolves the tenant of the request, converted to the type of the tenant field
+//
+// It returns nil if the model is not scoped by tenant
+func (self *CrudCtrl[T]) Tenant(c *gin.Context) (interface{}, error) {
+	field := self.TenantField()
+	if field == nil {
+		return nil, nil
+	}
+	tenant, err := self.options().TenantResolver()(c)
+	if err != nil {
+		return nil, WrapHttpError(ErrorStatus(err, http.StatusForbidden), err)
+	}
+	if tenant == nil || tenant == "" {
+		return nil, NewHttpError(http.StatusForbidden, "The tenant of the request is unknown")
+	}
+	if str, ok := tenant.(string); ok && field.FieldType.Kind() != reflect.String {
+		if tenant, err = parseValue(field.FieldType, str); err != nil {
+			return nil, NewHttpError(http.StatusForbidden, fmt.Sprintf("Invalid tenant: %s", str))
+		}
+	} else if !ok && field.FieldType.Kind() == reflect.String {
+		tenant = fmt.Sprint(tenant)
+	}
+	return tenant, nil
+}
+
+// scopeTenant restricts the query to the items of the tenant of the request
+//
+// If the tenant can not be resolved the error is added to the query, so it fails when executed
+func (self *CrudCtrl[T]) scopeTenant(c *gin.Context, db *gorm.DB) *gorm.DB {
+	field := self.TenantField()
+	if field == nil {
+		return db
+	}
+	tenant, err := self.Tenant(c)
+	if err != nil {
+		db = db.Session(&gorm.Session{})
+		_ = db.AddError(err)
+		return db
+	}
+	return db.Where(map[string]interface{}{field.DBName: tenant})
+}
+
+// stampTenant sets the tenant of the request on the item, so it can not be saved for another tenant
+func (self *CrudCtrl[T]) stampTenant(c *gin.Context, item *T) error {
+	field := self.TenantField()
+	if field == nil {
+		return nil
+	}
+	tenant, err := self.Tenant(c)
+	if err != nil {
+		return err
+	}
+	return field.Set(c, reflect.ValueOf(item).Elem(), tenant)
+}
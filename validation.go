@@ -0,0 +1,168 @@
+package crudex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// ValidationError is returned when the submitted item is not valid, it is sent to the client with status 422
+//
+// The errors are mapped by the name of the model field, so the form templates can show them next to each input
+type ValidationError struct {
+	// Message is an error that is not related to a single field
+	Message string
+	// Errors maps the name of the invalid fields to their error message
+	Errors map[string]string
+}
+
+// NewValidationError creates an empty ValidationError, use `Add` to add the field errors
+func NewValidationError() *ValidationError {
+	return &ValidationError{Errors: map[string]string{}}
+}
+
+// Add adds the error message of the field, the first error of a field is kept
+func (self *ValidationError) Add(field string, message string) *ValidationError {
+	if _, ok := self.Errors[field]; !ok {
+		self.Errors[field] = message
+	}
+	return self
+}
+
+// HasErrors returns true if there is any error in the validation error
+func (self *ValidationError) HasErrors() bool {
+	return self.Message != "" || len(self.Errors) > 0
+}
+
+func (self *ValidationError) Error() string {
+	if self.Message != "" {
+		return self.Message
+	}
+	messages := []string{}
+	for _, message := range self.Errors {
+		messages = append(messages, message)
+	}
+	sort.Strings(messages)
+	return fmt.Sprintf("Validation failed: %s", strings.Join(messages, ", "))
+}
+
+// IValidatable is implemented by the models that validate themselves
+//
+// Validate is called after the `validate` tags are checked. Returning a *ValidationError reports the errors by field,
+// any other error is reported as a message of the whole item
+type IValidatable interface {
+	Validate() error
+}
+
+var (
+	validateOnce sync.Once
+	validate     *validator.Validate
+)
+
+// Validator returns the validator that checks the `validate` tags of the models
+//
+// Custom validations can be registered on it with `RegisterValidation`
+func Validator() *validator.Validate {
+	validateOnce.Do(func() {
+		validate = validator.New(validator.WithRequiredStructEnabled())
+	})
+	return validate
+}
+
+// Validate validates the item with its `validate` tags and its `Validate()` method
+//
+// It returns a *ValidationError if the item is not valid
+func (self *CrudCtrl[T]) Validate(c *gin.Context, item *T) error {
+	result := NewValidationError()
+	var fieldErrors validator.ValidationErrors
+	if err := Validator().Struct(item); errors.As(err, &fieldErrors) {
+		for _, fieldError := range fieldErrors {
+			result.Add(fieldError.Field(), validationMessage(fieldError))
+		}
+	} else if err != nil {
+		return err
+	}
+	if validatable, ok := any(item).(IValidatable); ok {
+		var modelErr *ValidationError
+		if err := validatable.Validate(); errors.As(err, &modelErr) {
+			if result.Message == "" {
+				result.Message = modelErr.Message
+			}
+			for field, message := range modelErr.Errors {
+				result.Add(field, message)
+			}
+		} else if err != nil {
+			result.Message = err.Error()
+		}
+	}
+	if result.HasErrors() {
+		return result
+	}
+	return nil
+}
+
+// validationMessage returns a readable message for the failed `validate` tag
+func validationMessage(err validator.FieldError) string {
+	field := err.Field()
+	switch err.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", field)
+	case "email":
+		return fmt.Sprintf("%s must be a valid email address", field)
+	case "url":
+		return fmt.Sprintf("%s must be a valid url", field)
+	case "min", "gte":
+		return fmt.Sprintf("%s must be at least %s", field, err.Param())
+	case "max", "lte":
+		return fmt.Sprintf("%s must be at most %s", field, err.Param())
+	case "gt":
+		return fmt.Sprintf("%s must be greater than %s", field, err.Param())
+	case "lt":
+		return fmt.Sprintf("%s must be less than %s", field, err.Param())
+	case "len":
+		return fmt.Sprintf("%s must have a length of %s", field, err.Param())
+	case "oneof":
+		return fmt.Sprintf("%s must be one of %s", field, err.Param())
+	default:
+		return fmt.Sprintf("%s is not valid(%s)", field, err.Tag())
+	}
+}
+
+// jsonValidationError converts the json type errors in a *ValidationError, any other error is returned as it is
+func jsonValidationError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return NewValidationError().Add(typeErr.Field, fmt.Sprintf("%s must be a %s", typeErr.Field, typeErr.Type))
+	}
+	return err
+}
+
+// failValidation responds to an invalid item
+//
+// The Htmx requests get the form template re-rendered with the `Errors` by field, the general `Error` message
+// and the submitted `Values`, so the user can fix the input. Every other request fails with status 422
+//
+// The status is used for the errors that are not validation errors, see `fail`
+func (self *CrudCtrl[T]) failValidation(c *gin.Context, status int, err error, item *T, current *T) {
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || c.GetHeader("HX-Request") != "true" || negotiate(c, self.Config) != responseUI {
+		self.fail(c, status, err)
+		return
+	}
+	if self.reportError(c, err) {
+		return
+	}
+	data := self.formData(c, *item, current)
+	data["Errors"] = validationErr.Errors
+	data["Error"] = validationErr.Message
+	data["Values"] = c.Request.PostForm
+	self.respondWithStatus(c, http.StatusUnprocessableEntity, data, fmt.Sprintf("%s-form.html", strings.ToLower(self.ModelName)))
+	c.Abort()
+}
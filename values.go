@@ -0,0 +1,134 @@
+package crudex
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errUnsupportedType is returned by parseValue for the types that can not be converted from text
+var errUnsupportedType = errors.New("Unsupported type")
+
+// formatValue converts the value of a field to a plain value, the times are formatted with RFC3339 and the pointers are dereferenced
+//
+// It is the reverse of parseValue, so the exported values can be imported back
+func formatValue(value interface{}) interface{} {
+	if _, ok := value.(time.Time); !ok {
+		if valuer, ok := value.(driver.Valuer); ok {
+			value, _ = valuer.Value()
+		}
+	}
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case []byte:
+		return string(v)
+	}
+	if rv := reflect.ValueOf(value); rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		return formatValue(rv.Elem().Interface())
+	}
+	return value
+}
+
+// parseValue converts the text into a value of the given type, it is used for the keys, the filters, the forms and the imports
+//
+// The times are parsed as dates(FilterDateFormat) or RFC3339, the bools accept `checked` and `on` as well as the strconv values.
+// The pointers are parsed as their element, the types that implement encoding.TextUnmarshaler or sql.Scanner parse themselves
+func parseValue(typ reflect.Type, str string) (interface{}, error) {
+	if typ.Kind() == reflect.Pointer {
+		value, err := parseValue(typ.Elem(), str)
+		if err != nil {
+			return nil, err
+		}
+		ptr := reflect.New(typ.Elem())
+		ptr.Elem().Set(reflect.ValueOf(value))
+		return ptr.Interface(), nil
+	}
+	if typ == reflect.TypeOf(time.Time{}) {
+		if date, err := time.Parse(FilterDateFormat, str); err == nil {
+			return date, nil
+		}
+		return time.Parse(time.RFC3339Nano, str)
+	}
+	ptr := reflect.New(typ)
+	switch target := ptr.Interface().(type) {
+	case encoding.TextUnmarshaler:
+		if err := target.UnmarshalText([]byte(str)); err != nil {
+			return nil, err
+		}
+		return ptr.Elem().Interface(), nil
+	case sql.Scanner:
+		if err := target.Scan(str); err != nil {
+			return nil, err
+		}
+		return ptr.Elem().Interface(), nil
+	}
+	val := ptr.Elem()
+	switch typ.Kind() {
+	case reflect.String:
+		val.SetString(str)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		num, err := strconv.ParseInt(str, 10, typ.Bits())
+		if err != nil {
+			return nil, err
+		}
+		val.SetInt(num)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		num, err := strconv.ParseUint(str, 10, typ.Bits())
+		if err != nil {
+			return nil, err
+		}
+		val.SetUint(num)
+	case reflect.Float32, reflect.Float64:
+		num, err := strconv.ParseFloat(str, typ.Bits())
+		if err != nil {
+			return nil, err
+		}
+		val.SetFloat(num)
+	case reflect.Bool:
+		if strings.EqualFold(str, "checked") || strings.EqualFold(str, "on") {
+			val.SetBool(true)
+			break
+		}
+		b, err := strconv.ParseBool(str)
+		if err != nil {
+			return nil, err
+		}
+		val.SetBool(b)
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnsupportedType, typ)
+	}
+	return val.Interface(), nil
+}
+
+// invalidValueMessage returns the message of a value that can not be converted to the type of the field
+func invalidValueMessage(name string, typ reflect.Type) string {
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fmt.Sprintf("%s must be a positive whole number", name)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fmt.Sprintf("%s must be a whole number", name)
+	case reflect.Float32, reflect.Float64:
+		return fmt.Sprintf("%s must be a number", name)
+	case reflect.Bool:
+		return fmt.Sprintf("%s must be true or false", name)
+	}
+	if typ == reflect.TypeOf(time.Time{}) {
+		return fmt.Sprintf("%s must be a date", name)
+	}
+	return fmt.Sprintf("%s is not valid", name)
+}
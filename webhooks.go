@@ -0,0 +1,355 @@
+package crudex
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Headers of the webhook requests
+const (
+	// WebhookSignatureHeader holds the HMAC-SHA256 of the body signed with the secret of the webhook, e.g. `sha256=5d41...`
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	// WebhookEventHeader holds the name of the event, created, updated or deleted
+	WebhookEventHeader = "X-Webhook-Event"
+	// WebhookDeliveryHeader holds the ID of the delivery, it is the same for all the attempts of a delivery
+	WebhookDeliveryHeader = "X-Webhook-Delivery"
+)
+
+// Statuses of the webhook deliveries
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// IWebhookQueue queues the changes of the controllers for their webhooks, WebhookDispatcher is the default one
+type IWebhookQueue interface {
+	// Enqueue queues the event of a change of an item of the tenant, the tenant is empty for the models that are not scoped by tenant.
+	// It is called with the transaction of the change, so the event is queued only if the change is committed
+	Enqueue(tx *gorm.DB, event ChangeEvent, tenant string) error
+	// Committed is called after the transaction of the queued events is committed, e.g. to send them right away
+	Committed()
+}
+
+// Webhook is a url that receives the changes of the items of a model
+//
+// The table should be migrated with `db.AutoMigrate(&crudex.Webhook{}, &crudex.WebhookDelivery{})`
+type Webhook struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	// TenantID is the tenant of the changes that are sent, the webhooks without a tenant receive only the changes of the models that are not scoped by tenant
+	TenantID string `gorm:"index"`
+	// Model is the name of the model, e.g. `Car`, the changes of every model are sent if it is empty
+	Model string `gorm:"index"`
+	// Events are the comma separated events that are sent, e.g. `created,deleted`, every event is sent if it is empty
+	Events string
+	// URL receives the events as POST requests with a json body
+	URL string
+	// Secret signs the body of the requests, the requests are not signed if it is empty
+	Secret string `json:"-"`
+	// Disabled webhooks do not receive new events
+	Disabled bool
+}
+
+// the webhooks are scoped by their own tenant field, whatever the tenant field of the models is
+func (Webhook) tenantField() string {
+	return "TenantID"
+}
+
+// Accepts returns true if the webhook receives the event of the model
+func (self *Webhook) Accepts(model string, event string) bool {
+	if self.Disabled || self.Model != "" && self.Model != model {
+		return false
+	}
+	if strings.TrimSpace(self.Events) == "" {
+		return true
+	}
+	for _, name := range strings.Split(self.Events, ",") {
+		if strings.TrimSpace(name) == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is a change that is sent to a webhook
+//
+// The pending deliveries are the queue of the dispatcher, the delivered and failed ones are the log of the webhook
+type WebhookDelivery struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	WebhookID uint `gorm:"index"`
+	// Event is the name of the event, created, updated or deleted
+	Event string
+	// Model is the name of the model of the changed item
+	Model string
+	// RecordID is the route key of the changed item
+	RecordID string
+	// Payload is the json body of the requests, a ChangeEvent
+	Payload string
+	// Status is pending, delivered or failed
+	Status string `gorm:"index:idx_webhook_due"`
+	// Attempts is the number of the requests that were sent
+	Attempts int
+	// NextAttemptAt is the time of the next attempt of a pending delivery
+	NextAttemptAt time.Time `gorm:"index:idx_webhook_due"`
+	// ResponseStatus is the http status of the last attempt, 0 if there was no response
+	ResponseStatus int
+	// Error is the error of the last attempt
+	Error string
+	// DeliveredAt is the time of the successful attempt
+	DeliveredAt *time.Time
+}
+
+// SignWebhook returns the signature of the body, the hex encoded HMAC-SHA256 with the `sha256=` prefix
+func SignWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return fmt.Sprintf("sha256=%s", hex.EncodeToString(mac.Sum(nil)))
+}
+
+// VerifyWebhook returns true if the signature of the X-Webhook-Signature header matches the body, it is meant for the receivers
+func VerifyWebhook(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(SignWebhook(secret, body)), []byte(signature))
+}
+
+// WebhookDispatcher queues the changes of the controllers for their webhooks and delivers them
+//
+// The deliveries are stored in the database before they are sent, so they survive a restart. They are sent by `ProcessDue`,
+// which is called periodically by `Start`. A failed delivery is retried with backoff until MaxAttempts is reached.
+// Every delivery is sent at least once, the receivers can skip the duplicates with the X-Webhook-Delivery header
+type WebhookDispatcher struct {
+	Db     *gorm.DB
+	Client *http.Client
+	// MaxAttempts is the number of attempts before a delivery fails
+	MaxAttempts int
+	// Backoff returns the delay after the given failed attempt
+	Backoff func(attempt int) time.Duration
+	// BatchSize is the number of deliveries that are sent by a single ProcessDue
+	BatchSize int
+
+	wake chan struct{}
+}
+
+// NewWebhookDispatcher creates a dispatcher that keeps the webhooks and the deliveries in the database
+//
+// It makes 5 attempts with an exponential backoff starting at 30 seconds and a timeout of 10 seconds per request
+func NewWebhookDispatcher(db *gorm.DB) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		Db:          db,
+		Client:      &http.Client{Timeout: 10 * time.Second},
+		MaxAttempts: 5,
+		Backoff:     ExponentialBackoff(30*time.Second, time.Hour),
+		BatchSize:   100,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// ExponentialBackoff doubles the delay after every attempt, starting with base and up to limit
+func ExponentialBackoff(base time.Duration, limit time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		delay := base
+		for i := 1; i < attempt && delay < limit; i++ {
+			delay *= 2
+		}
+		return min(delay, limit)
+	}
+}
+
+// Register stores the webhook
+func (self *WebhookDispatcher) Register(hook *Webhook) error {
+	return self.Db.Create(hook).Error
+}
+
+// Enqueue stores a delivery of the event for every webhook of the tenant that accepts it, in the transaction of the change
+func (self *WebhookDispatcher) Enqueue(tx *gorm.DB, event ChangeEvent, tenant string) error {
+	var hooks []Webhook
+	err := tx.Where("disabled = ? AND (model = ? OR model = '') AND tenant_id = ?", false, event.Model, tenant).Find(&hooks).Error
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	deliveries := []WebhookDelivery{}
+	for _, hook := range hooks {
+		if hook.Accepts(event.Model, event.Event) {
+			deliveries = append(deliveries, WebhookDelivery{
+				WebhookID:     hook.ID,
+				Event:         event.Event,
+				Model:         event.Model,
+				RecordID:      event.Key,
+				Payload:       string(payload),
+				Status:        DeliveryPending,
+				NextAttemptAt: time.Now(),
+			})
+		}
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return tx.Create(&deliveries).Error
+}
+
+// Committed wakes up a running dispatcher, so it sends the new deliveries right away
+func (self *WebhookDispatcher) Committed() {
+	select {
+	case self.wake <- struct{}{}:
+	default:
+	}
+}
+
+// ProcessDue sends the pending deliveries that are due and returns the number of the attempts
+//
+// The failed attempts are recorded on the deliveries, only the errors of the database are returned
+func (self *WebhookDispatcher) ProcessDue(ctx context.Context) (int, error) {
+	var due []WebhookDelivery
+	err := self.Db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", DeliveryPending, time.Now()).
+		Order("next_attempt_at, id").Limit(self.BatchSize).Find(&due).Error
+	if err != nil {
+		return 0, err
+	}
+	attempts := 0
+	for _, delivery := range due {
+		// the delivery is claimed by counting the attempt, another dispatcher that loaded it as well skips it
+		claim := self.Db.WithContext(ctx).Model(&WebhookDelivery{}).
+			Where("id = ? AND attempts = ? AND status = ?", delivery.ID, delivery.Attempts, DeliveryPending).
+			Update("attempts", delivery.Attempts+1)
+		if claim.Error != nil {
+			return attempts, claim.Error
+		}
+		if claim.RowsAffected == 0 {
+			continue
+		}
+		delivery.Attempts++
+		attempts++
+		if err := self.attempt(ctx, &delivery); err != nil {
+			return attempts, err
+		}
+	}
+	return attempts, nil
+}
+
+// attempt sends the delivery and records the result
+func (self *WebhookDispatcher) attempt(ctx context.Context, delivery *WebhookDelivery) error {
+	var hook Webhook
+	if err := self.Db.WithContext(ctx).First(&hook, delivery.WebhookID).Error; err != nil {
+		delivery.Status, delivery.Error = DeliveryFailed, fmt.Sprintf("The webhook is not available: %s", err)
+		return self.Db.WithContext(ctx).Save(delivery).Error
+	}
+	status, err := self.send(ctx, &hook, delivery)
+	delivery.ResponseStatus = status
+	if err == nil {
+		now := time.Now()
+		delivery.Status, delivery.Error, delivery.DeliveredAt = DeliveryDelivered, "", &now
+	} else {
+		delivery.Error = err.Error()
+		if delivery.Attempts >= self.MaxAttempts {
+			delivery.Status = DeliveryFailed
+		} else {
+			delivery.NextAttemptAt = time.Now().Add(self.Backoff(delivery.Attempts))
+		}
+		slog.Warn("Failed to deliver the webhook", slog.String("url", hook.URL), slog.Uint64("delivery", uint64(delivery.ID)),
+			slog.Int("attempt", delivery.Attempts), slog.Any("error", err))
+	}
+	return self.Db.WithContext(ctx).Save(delivery).Error
+}
+
+// send posts the payload of the delivery to the webhook, any status other than 2xx is an error
+func (self *WebhookDispatcher) send(ctx context.Context, hook *Webhook, delivery *WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, delivery.Event)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatUint(uint64(delivery.ID), 10))
+	if hook.Secret != "" {
+		req.Header.Set(WebhookSignatureHeader, SignWebhook(hook.Secret, body))
+	}
+	res, err := self.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("The webhook responded with %s", res.Status)
+	}
+	return res.StatusCode, nil
+}
+
+// Start sends the due deliveries in the background until the context is done
+//
+// The queue is checked every interval and right after a change is enqueued
+func (self *WebhookDispatcher) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if _, err := self.ProcessDue(ctx); err != nil && ctx.Err() == nil {
+				slog.Error("Failed to process the webhook deliveries", slog.Any("error", err))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-self.wake:
+			}
+		}
+	}()
+}
+
+// Deliveries returns the log of the deliveries of the webhook, with the newest first
+func (self *WebhookDispatcher) Deliveries(webhookID uint) ([]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+	err := self.Db.Where(&WebhookDelivery{WebhookID: webhookID}).Order("created_at desc, id desc").Find(&deliveries).Error
+	return deliveries, err
+}
+
+// enqueueWebhooks queues the change for the webhooks of the configuration in the transaction of the change
+//
+// The deliveries are filtered by the tenant of the changed item, so the webhooks of a tenant never receive the items of another one
+func (self *CrudCtrl[T]) enqueueWebhooks(c *gin.Context, tx *gorm.DB, change Change[T]) error {
+	queue := self.options().Webhooks()
+	if queue == nil {
+		return nil
+	}
+	event := change.Event(self.ModelName)
+	tenant := ""
+	if item, _ := event.Item.(*T); item != nil && self.TenantField() != nil {
+		// the pointer tenant fields are dereferenced, the unset ones have no tenant
+		if value, zero := self.TenantField().ValueOf(c, reflect.ValueOf(item).Elem()); !zero {
+			if value := reflect.Indirect(reflect.ValueOf(value)); value.IsValid() {
+				tenant = fmt.Sprint(value.Interface())
+			}
+		}
+	}
+	return queue.Enqueue(tx.WithContext(context.WithoutCancel(c.Request.Context())), event, tenant)
+}
+
+// webhooksCommitted tells the webhooks of the configuration that the queued changes are committed
+func (self *CrudCtrl[T]) webhooksCommitted() {
+	if queue := self.options().Webhooks(); queue != nil {
+		queue.Committed()
+	}
+}
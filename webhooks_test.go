@@ -0,0 +1,193 @@
+package crudex
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// webhookReceiver records the requests of the webhooks and fails the first `failures` of them
+type webhookReceiver struct {
+	mu       sync.Mutex
+	failures int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (self *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	body, _ := io.ReadAll(r.Body)
+	self.requests = append(self.requests, r)
+	self.bodies = append(self.bodies, body)
+	if self.failures > 0 {
+		self.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func newWebhookCtrl(t *testing.T) (*WebhookDispatcher, *gin.Engine, *gorm.DB) {
+	t.Helper()
+	ctrl, app, db := newTestCtrl[Car](t)
+	if err := db.AutoMigrate(&Webhook{}, &WebhookDelivery{}); err != nil {
+		t.Fatalf("Error migrating the webhooks: %s", err)
+	}
+	dispatcher := NewWebhookDispatcher(db)
+	dispatcher.Backoff = func(attempt int) time.Duration { return 0 }
+	ctrl.Config.(*Config).WithWebhooks(dispatcher)
+	return dispatcher, app, db
+}
+
+func TestWebhooks_SignedDeliveryWithRetries(t *testing.T) {
+	dispatcher, app, db := newWebhookCtrl(t)
+	receiver := &webhookReceiver{failures: 1}
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+	dispatcher.Register(&Webhook{Model: "Car", Events: "created, deleted", URL: srv.URL, Secret: "s3cret"})
+	dispatcher.Register(&Webhook{Model: "Team", URL: srv.URL})
+
+	doRequest(app, "PUT", "/cars/new", url.Values{"Name": {"golf"}})
+	doRequest(app, "POST", "/cars/1", url.Values{"Name": {"polo"}})
+
+	ctx := context.Background()
+	if n, err := dispatcher.ProcessDue(ctx); err != nil || n != 1 {
+		t.Fatalf("Expected a single attempt of the created event, got %d %v", n, err)
+	}
+	var delivery WebhookDelivery
+	db.First(&delivery)
+	if delivery.Status != DeliveryPending || delivery.Attempts != 1 || delivery.ResponseStatus != http.StatusServiceUnavailable {
+		t.Errorf("Expected the failed attempt to be retried, got %+v", delivery)
+	}
+
+	if n, err := dispatcher.ProcessDue(ctx); err != nil || n != 1 {
+		t.Fatalf("Expected the retry, got %d %v", n, err)
+	}
+	if n, _ := dispatcher.ProcessDue(ctx); n != 0 {
+		t.Errorf("Expected nothing to be due, got %d", n)
+	}
+	deliveries, _ := dispatcher.Deliveries(1)
+	if len(deliveries) != 1 || deliveries[0].Status != DeliveryDelivered || deliveries[0].Attempts != 2 || deliveries[0].DeliveredAt == nil {
+		t.Errorf("Expected the delivery to be logged, got %+v", deliveries)
+	}
+
+	req, body := receiver.requests[1], receiver.bodies[1]
+	if !VerifyWebhook("s3cret", body, req.Header.Get(WebhookSignatureHeader)) {
+		t.Errorf("Expected a valid signature, got %s", req.Header.Get(WebhookSignatureHeader))
+	}
+	if req.Header.Get(WebhookEventHeader) != EventCreated || req.Header.Get(WebhookDeliveryHeader) != "1" {
+		t.Errorf("Unexpected headers %v", req.Header)
+	}
+	var event struct {
+		ChangeEvent
+		Item Car `json:"item"`
+	}
+	if err := json.Unmarshal(body, &event); err != nil || event.Model != "Car" || event.Key != "1" || event.Item.Name != "golf" {
+		t.Errorf("Unexpected payload %s", body)
+	}
+}
+
+func TestWebhooks_FailAfterMaxAttempts(t *testing.T) {
+	dispatcher, app, db := newWebhookCtrl(t)
+	dispatcher.MaxAttempts = 2
+	receiver := &webhookReceiver{failures: 10}
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+	dispatcher.Register(&Webhook{URL: srv.URL})
+
+	doRequest(app, "PUT", "/cars/new", url.Values{"Name": {"golf"}})
+	for i := 0; i < 3; i++ {
+		dispatcher.ProcessDue(context.Background())
+	}
+	var delivery WebhookDelivery
+	db.First(&delivery)
+	if delivery.Status != DeliveryFailed || delivery.Attempts != 2 || len(receiver.requests) != 2 || delivery.Error == "" {
+		t.Errorf("Expected the delivery to fail after 2 attempts, got %+v", delivery)
+	}
+}
+
+func TestWebhooks_FilteredByTenant(t *testing.T) {
+	ctrl, app, db := newTestCtrl[Invoice](t)
+	if err := db.AutoMigrate(&Webhook{}, &WebhookDelivery{}); err != nil {
+		t.Fatalf("Error migrating the webhooks: %s", err)
+	}
+	dispatcher := NewWebhookDispatcher(db)
+	ctrl.Config.(*Config).WithTenantResolver(TenantFromHeader("X-Tenant")).WithWebhooks(dispatcher)
+	dispatcher.Register(&Webhook{TenantID: "1", URL: "http://tenant1"})
+	dispatcher.Register(&Webhook{TenantID: "2", URL: "http://tenant2"})
+
+	for _, tenant := range []string{"1", "2", "1"} {
+		req := httptest.NewRequest("PUT", "/cars/new", strings.NewReader(`{"Number":"A-1"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Tenant", tenant)
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected the invoice to be created, got %d %s", w.Code, w.Body.String())
+		}
+	}
+	if deliveries, _ := dispatcher.Deliveries(1); len(deliveries) != 2 {
+		t.Errorf("Expected the events of the tenant 1 only, got %+v", deliveries)
+	}
+	if deliveries, _ := dispatcher.Deliveries(2); len(deliveries) != 1 {
+		t.Errorf("Expected the events of the tenant 2 only, got %+v", deliveries)
+	}
+}
+
+type Receipt struct {
+	ID       uint
+	TenantID *uint
+	Number   string
+}
+
+func TestWebhooks_PointerTenantField(t *testing.T) {
+	ctrl, app, db := newTestCtrl[Receipt](t)
+	if err := db.AutoMigrate(&Webhook{}, &WebhookDelivery{}); err != nil {
+		t.Fatalf("Error migrating the webhooks: %s", err)
+	}
+	dispatcher := NewWebhookDispatcher(db)
+	ctrl.Config.(*Config).WithTenantResolver(TenantFromHeader("X-Tenant")).WithWebhooks(dispatcher)
+	dispatcher.Register(&Webhook{TenantID: "7", URL: "http://tenant7"})
+
+	req := httptest.NewRequest("PUT", "/cars/new", strings.NewReader(`{"Number":"R-1"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Tenant", "7")
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected the receipt to be created, got %d %s", w.Code, w.Body.String())
+	}
+	if deliveries, _ := dispatcher.Deliveries(1); len(deliveries) != 1 {
+		t.Errorf("Expected the event to be queued for the tenant of the pointer field, got %+v", deliveries)
+	}
+}
+
+func TestWebhooks_NotQueuedForRolledBackWrites(t *testing.T) {
+	dispatcher, app, db := newWebhookCtrl(t)
+	dispatcher.Register(&Webhook{URL: "http://example.com"})
+
+	doBody(app, "PUT", "/cars/bulk", "application/json", strings.NewReader(`[{"ID":1,"Name":"golf"},{"ID":1,"Name":"polo"}]`))
+	doBody(app, "PUT", "/cars/bulk?dry_run=true", "application/json", strings.NewReader(`[{"Name":"polo"}]`))
+	var count int64
+	db.Model(&WebhookDelivery{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("Expected no deliveries for the rolled back writes, got %d", count)
+	}
+
+	doBody(app, "PUT", "/cars/bulk", "application/json", strings.NewReader(`[{"Name":"golf"},{"Name":"polo"}]`))
+	db.Model(&WebhookDelivery{}).Count(&count)
+	if count != 2 {
+		t.Errorf("Expected a delivery for each committed item, got %d", count)
+	}
+}